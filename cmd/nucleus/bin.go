@@ -9,6 +9,7 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"sync"
 	"time"
@@ -20,8 +21,10 @@ import (
 	"github.com/LambdaTest/synapse/pkg/command"
 	"github.com/LambdaTest/synapse/pkg/core"
 	"github.com/LambdaTest/synapse/pkg/diffmanager"
+	"github.com/LambdaTest/synapse/pkg/fileutils"
 	"github.com/LambdaTest/synapse/pkg/gitmanager"
 	"github.com/LambdaTest/synapse/pkg/global"
+	"github.com/LambdaTest/synapse/pkg/logstream"
 	"github.com/LambdaTest/synapse/pkg/lumber"
 	"github.com/LambdaTest/synapse/pkg/payloadmanager"
 	"github.com/LambdaTest/synapse/pkg/secret"
@@ -34,6 +37,7 @@ import (
 	"github.com/LambdaTest/synapse/pkg/testblocklistservice"
 	"github.com/LambdaTest/synapse/pkg/testdiscoveryservice"
 	"github.com/LambdaTest/synapse/pkg/testexecutionservice"
+	"github.com/LambdaTest/synapse/pkg/utils"
 	"github.com/LambdaTest/synapse/pkg/zstd"
 	"github.com/spf13/cobra"
 )
@@ -50,6 +54,8 @@ func RootCommand() *cobra.Command {
 	// define flags used for this command
 	AttachCLIFlags(&rootCmd)
 
+	rootCmd.AddCommand(ConfigCommand())
+
 	return &rootCmd
 }
 
@@ -81,6 +87,10 @@ func run(cmd *cobra.Command, args []string) {
 	if err != nil {
 		log.Fatalf("Could not instantiate logger %s", err.Error())
 	}
+	// the same ID is attached to the logs and the requests to neuron to trace the build, the pipeline
+	// falls back to the build ID once the payload is fetched
+	global.SetCorrelationID(strings.TrimSpace(cfg.CorrelationID))
+	logger = utils.CorrelationLogger(logger)
 	logger.Debugf("Running on local: %t", cfg.LocalRunner)
 
 	if cfg.LocalRunner {
@@ -110,31 +120,56 @@ func run(cmd *cobra.Command, args []string) {
 		logger.Fatalf("failed to initialize azure blob: %v", err)
 	}
 
+	if err := fileutils.CheckWritableDir(cfg.TempDir); err != nil {
+		logger.Fatalf("temp dir %s is not writable: %v", cfg.TempDir, err)
+	}
+
+	neuronHeaders, err := utils.ParseHeaders(cfg.NeuronHeaders)
+	if err != nil {
+		logger.Fatalf("invalid neuron headers: %v", err)
+	}
+	global.SetNeuronHeaders(neuronHeaders)
+
+	maskPatterns := cfg.MaskPatterns
+	for _, value := range neuronHeaders {
+		// header values usually carry credentials
+		if len(value) > 1 {
+			maskPatterns = append(maskPatterns, regexp.QuoteMeta(value))
+		}
+	}
+	if err := logstream.RegisterPatterns(maskPatterns); err != nil {
+		logger.Fatalf("failed to register mask patterns: %v", err)
+	}
+	logstream.RegisterPathPrefixes(cfg.MaskPaths)
+
 	// attach plugins to pipeline
 	pm := payloadmanager.NewPayloadManger(azureClient, logger, cfg)
-	secretParser := secret.New(logger)
-	tcm := tasconfigmanager.NewTASConfigManager(logger)
-	gm := gitmanager.NewGitManager(logger)
+	secretParser := secret.New(cfg, logger)
+	tcm := tasconfigmanager.NewTASConfigManager(cfg, logger)
+	gm := gitmanager.NewGitManager(cfg, logger)
 	dm := diffmanager.NewDiffManager(cfg, logger)
-	execManager := command.NewExecutionManager(secretParser, azureClient, logger)
-	tds := testdiscoveryservice.NewTestDiscoveryService(execManager, logger)
-	tes := testexecutionservice.NewTestExecutionService(execManager, azureClient, ts, logger)
+	execManager := command.NewExecutionManager(secretParser, azureClient, cfg, logger)
+	tds := testdiscoveryservice.NewTestDiscoveryService(execManager, cfg, logger)
+	tes, err := testexecutionservice.NewTestExecutionService(execManager, azureClient, ts, cfg, logger)
+	if err != nil {
+		logger.Fatalf("failed to initialize test execution service: %v", err)
+	}
 	tbs, err := testblocklistservice.NewTestBlockListService(cfg, logger)
 	if err != nil {
 		logger.Fatalf("failed to initialize test blocklist service: %v", err)
 	}
-	router := api.NewRouter(logger, ts)
+	router := api.NewRouter(cfg, logger, ts)
 
 	t, err := task.New(ctx, cfg, logger)
 	if err != nil {
 		logger.Fatalf("failed to initialize task: %v", err)
 	}
 
-	zstd, err := zstd.New(execManager, logger)
+	zstd, err := zstd.New(execManager, cfg, logger)
 	if err != nil {
 		logger.Fatalf("failed to initialize zstd compressor: %v", err)
 	}
-	cache, err := cachemanager.New(zstd, azureClient, logger)
+	cache, err := cachemanager.New(zstd, azureClient, cfg, logger)
 	if err != nil {
 		logger.Fatalf("failed to initialize cache manager: %v", err)
 	}
@@ -165,12 +200,17 @@ func run(cmd *cobra.Command, args []string) {
 
 	logger.Infof("LambdaTest Nucleus version: %s", global.NUCLEUS_BINARY_VERSION)
 
+	// the task modes report their errors through the task status,
+	// a failure in coverage mode is reported through the exit code
+	var exitCode int
 	wg.Add(1)
 	go func() {
 		defer cancel()
 		defer wg.Done()
 		// starting pipeline
-		pl.Start(ctx)
+		if err := pl.Start(ctx); err != nil && cfg.CoverageMode {
+			exitCode = 1
+		}
 	}()
 	wg.Add(1)
 	go func() {
@@ -212,7 +252,7 @@ func run(cmd *cobra.Command, args []string) {
 
 		}
 	case <-done:
-		os.Exit(0)
+		os.Exit(exitCode)
 	}
 
 }
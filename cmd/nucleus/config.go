@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"strings"
+
+	"github.com/LambdaTest/synapse/config"
+	"github.com/LambdaTest/synapse/pkg/core"
+	"github.com/LambdaTest/synapse/pkg/lumber"
+	"github.com/LambdaTest/synapse/pkg/tasconfigmanager"
+	"github.com/spf13/cobra"
+)
+
+// ConfigCommand will setup and return the config command
+func ConfigCommand() *cobra.Command {
+	configCmd := cobra.Command{
+		Use:   "config",
+		Short: "Print the tas configuration",
+		Long:  `config prints the tas configuration file, use --effective to print the resolved configuration nucleus runs with`,
+		RunE:  runConfig,
+	}
+
+	configCmd.Flags().Bool("effective", false, "Print the resolved configuration with all the defaults applied")
+	configCmd.Flags().String("repoDir", ".", "Path of the repository containing the configuration file")
+	configCmd.Flags().String("path", ".tas.yml", "Path of the configuration file relative to the repository")
+	configCmd.Flags().String("event", string(core.EventPush), "Event type to resolve the configuration for (push|pull-request)")
+	configCmd.Flags().StringP("format", "o", "yaml", "Output format (yaml|json)")
+
+	return &configCmd
+}
+
+func runConfig(cmd *cobra.Command, args []string) error {
+	effective, _ := cmd.Flags().GetBool("effective")
+	repoDir, _ := cmd.Flags().GetString("repoDir")
+	path, _ := cmd.Flags().GetString("path")
+	event, _ := cmd.Flags().GetString("event")
+	format, _ := cmd.Flags().GetString("format")
+
+	if !effective {
+		rawBytes, err := ioutil.ReadFile(fmt.Sprintf("%s/%s", repoDir, path))
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(cmd.OutOrStdout(), string(rawBytes))
+		return nil
+	}
+
+	eventType := core.EventType(event)
+	if eventType != core.EventPush && eventType != core.EventPullRequest {
+		return errors.New("event must be one of push or pull-request")
+	}
+
+	logger, err := lumber.NewLogger(lumber.LoggingConfig{EnableConsole: true}, false, lumber.InstanceZapLogger)
+	if err != nil {
+		log.Fatalf("Could not instantiate logger %s", err.Error())
+	}
+	// the configuration is resolved with the same nucleus config as a run, e.g. its default tier
+	cfg, err := config.LoadNucleusConfig(cmd)
+	if err != nil {
+		return err
+	}
+	tcm := tasconfigmanager.NewTASConfigManager(cfg, logger)
+	tasConfig, err := tcm.LoadConfigFromDir(context.Background(), repoDir, path, eventType, false)
+	if err != nil {
+		return err
+	}
+	rawBytes, err := tasconfigmanager.MarshalConfig(tasConfig, format)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(cmd.OutOrStdout(), strings.TrimSpace(string(rawBytes)))
+	return nil
+}
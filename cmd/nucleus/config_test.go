@@ -0,0 +1,35 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunConfigEffective(t *testing.T) {
+	// the fixture of the effective configuration does not set a tier
+	repoDir := filepath.Join("..", "..", "pkg", "tasconfigmanager", "testdata")
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"default tier", nil, "tier: small"},
+		// the tier the operator sets for a run is the one resolved
+		{"operator tier", []string{"--defaultTier", "large"}, "tier: large"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rootCmd := RootCommand()
+			var out bytes.Buffer
+			rootCmd.SetOut(&out)
+			rootCmd.SetArgs(append([]string{"config", "--effective", "--repoDir", repoDir}, tt.args...))
+			if err := rootCmd.Execute(); err != nil {
+				t.Fatalf("config --effective error = %v", err)
+			}
+			assert.Contains(t, out.String(), tt.want)
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"github.com/LambdaTest/synapse/pkg/global"
 	"github.com/spf13/cobra"
 )
 
@@ -9,7 +10,7 @@ func AttachCLIFlags(rootCmd *cobra.Command) error {
 
 	rootCmd.PersistentFlags().StringP("config", "c", "", "the config file to use")
 	rootCmd.PersistentFlags().StringP("port", "p", "", "Port for api server to run")
-	rootCmd.PersistentFlags().StringP("payloadAddress", "l", "", "Payload address")
+	rootCmd.PersistentFlags().StringP("payloadAddress", "l", "", "Payload address, one of file://path, env://VAR or https://url")
 	rootCmd.PersistentFlags().BoolP("verbose", "", false, "Run in verbose mode")
 	rootCmd.PersistentFlags().BoolP("coverage", "", false, "Run coverage only mode")
 	rootCmd.PersistentFlags().BoolP("parser", "", false, "Run YML parsing only mode")
@@ -24,6 +25,57 @@ func AttachCLIFlags(rootCmd *cobra.Command) error {
 	rootCmd.PersistentFlags().String("baseCommit", "", "The base commit for nucleus")
 	rootCmd.PersistentFlags().StringP("synapsehost", "", "", "Local Ip of proxy server.")
 	rootCmd.PersistentFlags().BoolP("local", "", false, "local mode")
+	rootCmd.PersistentFlags().String("junitReport", "", "Path to write the JUnit XML report of executed tests")
+	rootCmd.PersistentFlags().String("emptyDiffFallback", "all", "Tests to discover when a pull request has an empty diff (all|none)")
+	rootCmd.PersistentFlags().Bool("strictSecrets", false, "Fail on references to undefined secrets and warn about unused secrets")
+	rootCmd.PersistentFlags().StringSlice("maskPatterns", []string{}, "Additional regex patterns to mask in logs")
+	rootCmd.PersistentFlags().StringSlice("maskPaths", []string{}, "Path prefixes whose paths are redacted in the logs and the reports")
+	rootCmd.PersistentFlags().String("cloneArchiveFormat", "zip", "Archive format used to clone the repo (zip|tar.gz)")
+	rootCmd.PersistentFlags().StringSlice("postCloneChecks", []string{}, "Paths which must exist in the repo after cloning")
+	rootCmd.PersistentFlags().StringSlice("metadata", []string{}, "Build metadata as key=value pairs added to the reports, limited to 4096 bytes in total")
+	rootCmd.PersistentFlags().String("blocklistFailureMode", "strict", "Behaviour when the blocklist can not be fetched (strict|lenient)")
+	rootCmd.PersistentFlags().Int("cloneDownloadConcurrency", 1, "Number of concurrent range requests used to download the repo archive")
+	rootCmd.PersistentFlags().Bool("offline", false, "Run without neuron, reading the payload from a local file and writing results to offlineDir")
+	rootCmd.PersistentFlags().String("offlineDir", global.OfflineDir, "Directory where results are written in offline mode")
+	rootCmd.PersistentFlags().Bool("gzipReports", false, "Gzip the test reports sent to neuron")
+	rootCmd.PersistentFlags().Int("statusUpdateRetries", 3, "Maximum number of retries for transient failures while updating the task status")
+	rootCmd.PersistentFlags().Int("killGracePeriod", 10, "Seconds given to a canceled command and its child processes to exit before they are killed")
+	rootCmd.PersistentFlags().String("diffFile", "", "Path of a unified diff file used for the changed files instead of the git provider API")
+	rootCmd.PersistentFlags().String("cloneSymlinkMode", "preserve", "Handling of symlinks to files in the cloned repo (preserve|materialize)")
+	rootCmd.PersistentFlags().String("tempDir", "", "Directory for temporary files, defaults to the temp dir of the OS")
+	rootCmd.PersistentFlags().Int("coverageUploadWorkers", 4, "Number of commits whose coverage is uploaded concurrently")
+	rootCmd.PersistentFlags().String("framework", "", "Override the framework of the tas configuration file (jest|mocha|jasmine)")
+	rootCmd.PersistentFlags().String("diffBaseCommit", "", "Explicit base commit for computing the changed files, takes precedence over the base derived from the event")
+	rootCmd.PersistentFlags().StringSlice("skipTestsTokens", []string{global.DefaultSkipTestsToken}, "Tokens which skip the tests when found in the commit message, case insensitive")
+	rootCmd.PersistentFlags().Int("commandLogLimit", 100, "Maximum output in MB logged per command, keeping its head and tail, 0 disables the limit")
+	rootCmd.PersistentFlags().String("gitUserName", "TAS Bot", "Name of the git identity used by the user's commands, overridden by gitIdentity in the tas configuration file")
+	rootCmd.PersistentFlags().String("gitUserEmail", "tas-bot@lambdatest.com", "Email of the git identity used by the user's commands, overridden by gitIdentity in the tas configuration file")
+	rootCmd.PersistentFlags().String("parallelismOverride", "cap", "Whether the TAS_PARALLELISM_OVERRIDE env var caps or replaces the parallelism of the tas configuration file (cap|replace)")
+	rootCmd.PersistentFlags().StringSlice("neuronHeaders", []string{}, "Headers as key=value pairs added to every request to neuron, their values are masked in the logs")
+	rootCmd.PersistentFlags().Int("maxCloneSize", 0, "Maximum size in MB of the repo archive, the clone is aborted when exceeded, 0 disables the limit")
+	rootCmd.PersistentFlags().String("retryResultRule", "last", "Final result of a retried test, from its last attempt or passed if any attempt passed (last|any-pass)")
+	rootCmd.PersistentFlags().String("emptySuitePolicy", "passed", "Status of an execution task without any test results (passed|no-tests|failed)")
+	rootCmd.PersistentFlags().Bool("captureTestOutput", false, "Attach the output reported by the runner to the results of failed tests, masked and limited to 64KB per test")
+	rootCmd.PersistentFlags().String("cacheBaseKey", "", "Key of a shared read-only base cache restored before the cache of the repo, which then only holds the changes to the base")
+	rootCmd.PersistentFlags().Bool("preserveWorkspaceOnFailure", false, "Upload a snapshot of the repo directory, without .git and node_modules, when the task fails")
+	rootCmd.PersistentFlags().Int("workspaceSnapshotLimit", 500, "Maximum size in MB of the files in the workspace snapshot, the remaining files are left out")
+	rootCmd.PersistentFlags().Int("passedResultSample", 0, "Maximum number of passed test results reported to neuron along with their total count, the other results are always reported, 0 reports all of them. Turned off for a build by the sampled_reports feature flag of the payload set to false")
+	rootCmd.PersistentFlags().Int("niceLevel", 0, "Nice level of the test discovery and execution commands on unix, raising it yields the CPU to other workloads, 0 leaves it unchanged")
+	rootCmd.PersistentFlags().IntSlice("nvmSuccessExitCodes", []int{global.NvmSourceExitCode}, "Exit codes of the node version install through nvm treated as success")
+	rootCmd.PersistentFlags().String("branchHook", "", "Command printing the branch of the task, which overrides the branch of the payload, the latter is passed to it as BRANCH_NAME")
+	rootCmd.PersistentFlags().Int("cacheExtractRetries", 1, "Number of times the cache is downloaded and extracted again when its extraction fails")
+	rootCmd.PersistentFlags().Bool("verifyCache", false, "Write a manifest of the file checksums with the cache and verify the extracted files against it, a mismatch or a missing manifest fails the task")
+	rootCmd.PersistentFlags().String("workDir", "", "Directory where the resolved diff, the discovery command and the tests assigned to the task are written for inspection")
+	rootCmd.PersistentFlags().String("correlationID", "", "ID attached to every log line and request to neuron to trace the build, defaults to the build ID")
+	rootCmd.PersistentFlags().Bool("cleanupAfterRun", false, "Remove the temporary files of the run from the temp dir when it ends, for persistent runners")
+	rootCmd.PersistentFlags().String("defaultTier", "small", "Tier of the tas configuration files which do not set one (xsmall|small|medium|large|xlarge)")
+	rootCmd.PersistentFlags().String("cloneMethod", "archive", "Method used to clone the repo, archive downloads it without the git metadata (archive|git)")
+	rootCmd.PersistentFlags().Int("cloneDepth", 0, "Number of commits fetched when the repo is cloned with git, 0 fetches the full history")
+	rootCmd.PersistentFlags().StringSlice("failureClasses", nil, "Status of the task as status=regex pairs when the output of a failed discovery or execution matches the regex (error|failed), defaults to common infrastructure failures")
+	rootCmd.PersistentFlags().String("blocklistCacheDir", "", "Directory where the remote blocklist is cached between runs, it is downloaded again only when it changed")
+	rootCmd.PersistentFlags().String("discoveryHook", "", "Command transforming the discovered tests, it reads the discovery result as json on stdin and prints the result to post on stdout")
+	rootCmd.PersistentFlags().String("unknownStatusPolicy", "error", "Handling of the test results with an unknown or empty status (error|failed|ignore)")
+	rootCmd.PersistentFlags().Int("cacheTimeout", 900, "Timeout in seconds for each cache operation, 0 disables the timeout")
 
 	return nil
 }
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/LambdaTest/synapse/config"
+	"github.com/LambdaTest/synapse/pkg/global"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestLoadNucleusConfig loads every flag into the config, so that a field of a type the config
+// parser does not support fails here rather than at startup
+func TestLoadNucleusConfig(t *testing.T) {
+	cmd := RootCommand()
+	if err := cmd.ParseFlags([]string{"--nvmSuccessExitCodes=3,4", "--skipTestsTokens=[skip ci]"}); err != nil {
+		t.Fatalf("failed to parse flags: %v", err)
+	}
+	cfg, err := config.LoadNucleusConfig(cmd)
+	if err != nil {
+		t.Fatalf("LoadNucleusConfig() error = %v", err)
+	}
+	assert.Equal(t, []int{3, 4}, cfg.NvmSuccessExitCodes)
+	assert.Equal(t, []string{"[skip ci]"}, cfg.SkipTestsTokens)
+	assert.Equal(t, global.OfflineDir, cfg.OfflineDir)
+}
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"os"
+
 	"github.com/LambdaTest/synapse/pkg/global"
 	"github.com/spf13/viper"
 )
@@ -16,6 +18,30 @@ func setNucleusDefaultConfig() {
 	viper.SetDefault("Env", "prod")
 	viper.SetDefault("Port", "9876")
 	viper.SetDefault("Verbose", false)
+	viper.SetDefault("cacheTimeout", 900)
+	viper.SetDefault("emptyDiffFallback", "all")
+	viper.SetDefault("cloneArchiveFormat", global.ArchiveFormatZip)
+	viper.SetDefault("cloneMethod", global.CloneMethodArchive)
+	viper.SetDefault("failureClasses", global.DefaultFailureClasses)
+	viper.SetDefault("defaultTier", "small")
+	viper.SetDefault("blocklistFailureMode", global.BlocklistFailureStrict)
+	viper.SetDefault("offlineDir", global.OfflineDir)
+	viper.SetDefault("statusUpdateRetries", 3)
+	viper.SetDefault("killGracePeriod", 10)
+	viper.SetDefault("cloneSymlinkMode", global.SymlinkModePreserve)
+	viper.SetDefault("tempDir", os.TempDir())
+	viper.SetDefault("coverageUploadWorkers", 4)
+	viper.SetDefault("commandLogLimit", 100)
+	viper.SetDefault("gitUserName", global.DefaultGitUserName)
+	viper.SetDefault("gitUserEmail", global.DefaultGitUserEmail)
+	viper.SetDefault("parallelismOverride", global.ParallelismOverrideCap)
+	viper.SetDefault("retryResultRule", global.RetryResultLast)
+	viper.SetDefault("emptySuitePolicy", global.EmptySuitePassed)
+	viper.SetDefault("unknownStatusPolicy", global.UnknownStatusError)
+	viper.SetDefault("workspaceSnapshotLimit", 500)
+	viper.SetDefault("cacheExtractRetries", 1)
+	viper.SetDefault("nvmSuccessExitCodes", []int{global.NvmSourceExitCode})
+	viper.SetDefault("skipTestsTokens", []string{global.DefaultSkipTestsToken})
 }
 
 func setSynapseDefaultConfig() {
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"os"
 	"strings"
 
 	"github.com/LambdaTest/synapse/pkg/lumber"
@@ -44,8 +45,9 @@ func LoadNucleusConfig(cmd *cobra.Command) (*NucleusConfig, error) {
 		viper.AddConfigPath("$HOME/.nucleus")
 	}
 
+	// the warning goes to stderr, the output of the config command is parsed
 	if err := viper.ReadInConfig(); err != nil {
-		fmt.Println("Warning: No configuration file found. Proceeding with defaults")
+		fmt.Fprintln(os.Stderr, "Warning: No configuration file found. Proceeding with defaults")
 	}
 
 	return populateNucleusConfig(new(NucleusConfig))
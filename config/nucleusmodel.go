@@ -6,26 +6,77 @@ import "github.com/LambdaTest/synapse/pkg/lumber"
 
 // NucleusConfig is the application's configuration
 type NucleusConfig struct {
-	Config         string
-	Port           string
-	PayloadAddress string `json:"payloadAddress" yaml:"payloadAddress"`
-	LogFile        string
-	LogConfig      lumber.LoggingConfig
-	CoverageMode   bool   `json:"coverage" yaml:"coverageOnly"`
-	ParseMode      bool   `json:"parser" yaml:"parseOnly"`
-	DiscoverMode   bool   `json:"discover" yaml:"discoverOnly"`
-	ExecuteMode    bool   `json:"execute" yaml:"executeOnly"`
-	TaskID         string `json:"taskID" env:"TASK_ID"`
-	BuildID        string `json:"buildID" env:"BUILD_ID"`
-	TargetCommit   string `json:"targetCommit" env:"TARGET_COMMIT_ID"`
-	BaseCommit     string `json:"baseCommit" env:"BASE_COMMIT_ID"`
-	Locators       string `json:"locators"`
-	LocatorAddress string `json:"locatorAddress"`
-	Env            string
-	Verbose        bool
-	Azure          Azure  `env:"AZURE"`
-	LocalRunner    bool   `env:"local"`
-	SynapseHost    string `env:"synapsehost"`
+	Config                     string
+	Port                       string
+	PayloadAddress             string `json:"payloadAddress" yaml:"payloadAddress"`
+	LogFile                    string
+	LogConfig                  lumber.LoggingConfig
+	CoverageMode               bool   `json:"coverage" yaml:"coverageOnly"`
+	ParseMode                  bool   `json:"parser" yaml:"parseOnly"`
+	DiscoverMode               bool   `json:"discover" yaml:"discoverOnly"`
+	ExecuteMode                bool   `json:"execute" yaml:"executeOnly"`
+	TaskID                     string `json:"taskID" env:"TASK_ID"`
+	BuildID                    string `json:"buildID" env:"BUILD_ID"`
+	TargetCommit               string `json:"targetCommit" env:"TARGET_COMMIT_ID"`
+	BaseCommit                 string `json:"baseCommit" env:"BASE_COMMIT_ID"`
+	Locators                   string `json:"locators"`
+	LocatorAddress             string `json:"locatorAddress"`
+	Env                        string
+	Verbose                    bool
+	Azure                      Azure    `env:"AZURE"`
+	LocalRunner                bool     `env:"local"`
+	SynapseHost                string   `env:"synapsehost"`
+	JUnitReport                string   `json:"junitReport"`
+	CacheTimeout               int      `json:"cacheTimeout"`
+	EmptyDiffFallback          string   `json:"emptyDiffFallback"`
+	StrictSecrets              bool     `json:"strictSecrets"`
+	MaskPatterns               []string `json:"maskPatterns"`
+	CloneArchiveFormat         string   `json:"cloneArchiveFormat"`
+	PostCloneChecks            []string `json:"postCloneChecks"`
+	Metadata                   []string `json:"metadata"`
+	BlocklistFailureMode       string   `json:"blocklistFailureMode"`
+	CloneDownloadConcurrency   int      `json:"cloneDownloadConcurrency"`
+	Offline                    bool     `json:"offline"`
+	OfflineDir                 string   `json:"offlineDir"`
+	GzipReports                bool     `json:"gzipReports"`
+	StatusUpdateRetries        int      `json:"statusUpdateRetries"`
+	KillGracePeriod            int      `json:"killGracePeriod"`
+	DiffFile                   string   `json:"diffFile"`
+	CloneSymlinkMode           string   `json:"cloneSymlinkMode"`
+	TempDir                    string   `json:"tempDir"`
+	CoverageUploadWorkers      int      `json:"coverageUploadWorkers"`
+	Framework                  string   `json:"framework"`
+	DiffBaseCommit             string   `json:"diffBaseCommit"`
+	SkipTestsTokens            []string `json:"skipTestsTokens"`
+	CommandLogLimit            int      `json:"commandLogLimit"`
+	GitUserName                string   `json:"gitUserName"`
+	GitUserEmail               string   `json:"gitUserEmail"`
+	ParallelismOverride        string   `json:"parallelismOverride"`
+	NeuronHeaders              []string `json:"neuronHeaders"`
+	MaxCloneSize               int      `json:"maxCloneSize"`
+	RetryResultRule            string   `json:"retryResultRule"`
+	EmptySuitePolicy           string   `json:"emptySuitePolicy"`
+	CaptureTestOutput          bool     `json:"captureTestOutput"`
+	CacheBaseKey               string   `json:"cacheBaseKey"`
+	PreserveWorkspaceOnFailure bool     `json:"preserveWorkspaceOnFailure"`
+	WorkspaceSnapshotLimit     int      `json:"workspaceSnapshotLimit"`
+	PassedResultSample         int      `json:"passedResultSample"`
+	NiceLevel                  int      `json:"niceLevel"`
+	NvmSuccessExitCodes        []int    `json:"nvmSuccessExitCodes"`
+	BranchHook                 string   `json:"branchHook"`
+	CacheExtractRetries        int      `json:"cacheExtractRetries"`
+	VerifyCache                bool     `json:"verifyCache"`
+	WorkDir                    string   `json:"workDir"`
+	CorrelationID              string   `json:"correlationID"`
+	CleanupAfterRun            bool     `json:"cleanupAfterRun"`
+	DefaultTier                string   `json:"defaultTier"`
+	CloneMethod                string   `json:"cloneMethod"`
+	CloneDepth                 int      `json:"cloneDepth"`
+	FailureClasses             []string `json:"failureClasses"`
+	BlocklistCacheDir          string   `json:"blocklistCacheDir"`
+	DiscoveryHook              string   `json:"discoveryHook"`
+	UnknownStatusPolicy        string   `json:"unknownStatusPolicy"`
+	MaskPaths                  []string `json:"maskPaths"`
 }
 
 // Azure providers the storage configuration.
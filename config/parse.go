@@ -84,6 +84,22 @@ func recursivelySet(val reflect.Value, prefix string) error {
 					continue
 				}
 				thisField.SetBool(viper.GetBool(key))
+			case reflect.Slice:
+				// only string and int slices are supported
+				var configVal reflect.Value
+				switch thisField.Type().Elem().Kind() {
+				case reflect.String:
+					configVal = reflect.ValueOf(viper.GetStringSlice(key))
+				case reflect.Int:
+					configVal = reflect.ValueOf(viper.GetIntSlice(key))
+				default:
+					return fmt.Errorf("unexpected slice type detected ~ aborting: %s", thisField.Type().Elem().Kind())
+				}
+				// skip the update if tag is not set in viper
+				if configVal.Len() == 0 && thisField.Len() != 0 {
+					continue
+				}
+				thisField.Set(configVal)
 			case reflect.Map:
 				continue
 			default:
@@ -40,3 +40,22 @@ func TestNestedValues(t *testing.T) {
 	assert.Equal(t, "i am a simple string", c.Nested.StringVal)
 	assert.Equal(t, true, c.Nested.BoolVal)
 }
+
+func TestSliceValues(t *testing.T) {
+	c := struct {
+		Strings []string `json:"strings"`
+		Ints    []int    `json:"ints"`
+	}{}
+
+	viper.SetDefault("strings", []string{"a", "b"})
+	viper.SetDefault("ints", []int{3, 4})
+
+	assert.Nil(t, recursivelySet(reflect.ValueOf(&c), ""))
+	assert.Equal(t, []string{"a", "b"}, c.Strings)
+	assert.Equal(t, []int{3, 4}, c.Ints)
+
+	unsupported := struct {
+		Floats []float64 `json:"floats"`
+	}{}
+	assert.EqualError(t, recursivelySet(reflect.ValueOf(&unsupported), ""), "unexpected slice type detected ~ aborting: float64")
+}
@@ -1,8 +1,11 @@
 package api
 
 import (
+	"github.com/LambdaTest/synapse/config"
 	"github.com/LambdaTest/synapse/pkg/api/health"
 	"github.com/LambdaTest/synapse/pkg/api/results"
+	"github.com/LambdaTest/synapse/pkg/api/testlist"
+	"github.com/LambdaTest/synapse/pkg/global"
 	"github.com/LambdaTest/synapse/pkg/lumber"
 	"github.com/LambdaTest/synapse/pkg/service/teststats"
 	"github.com/gin-gonic/gin"
@@ -10,13 +13,15 @@ import (
 
 // Router for nucleus
 type Router struct {
+	cfg              *config.NucleusConfig
 	logger           lumber.Logger
 	testStatsService *teststats.ProcStats
 }
 
 // NewRouter returns instance of Router
-func NewRouter(logger lumber.Logger, ts *teststats.ProcStats) Router {
+func NewRouter(cfg *config.NucleusConfig, logger lumber.Logger, ts *teststats.ProcStats) Router {
 	return Router{
+		cfg:              cfg,
 		logger:           logger,
 		testStatsService: ts,
 	}
@@ -33,6 +38,11 @@ func (r Router) Handler() *gin.Engine {
 	// router.Use(cors.New(corsConfig))
 	router.GET("/health", health.Handler)
 	router.POST("/results", results.Handler(r.logger, r.testStatsService))
+	if r.cfg.Offline {
+		router.POST("/test-list", testlist.Handler(r.logger, r.cfg.OfflineDir, r.cfg.DiscoveryHook))
+	} else {
+		router.POST("/test-list", testlist.ForwardHandler(r.logger, r.cfg.DiscoveryHook, global.NeuronHost+"/test-list"))
+	}
 
 	return router
 
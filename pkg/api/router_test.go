@@ -0,0 +1,167 @@
+package api
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/LambdaTest/synapse/config"
+	"github.com/LambdaTest/synapse/pkg/global"
+	"github.com/LambdaTest/synapse/pkg/lumber"
+	"github.com/LambdaTest/synapse/pkg/service/teststats"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOfflineTestList(t *testing.T) {
+	logger, err := lumber.NewLogger(lumber.LoggingConfig{EnableConsole: true}, true, lumber.InstanceZapLogger)
+	if err != nil {
+		log.Fatalf("Could not instantiate logger %s", err.Error())
+	}
+	body := `{"tests": [{"title": "adds two numbers"}]}`
+
+	neuron := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer neuron.Close()
+	defer global.SetNeuronHost(global.NeuronHost)
+	global.SetNeuronHost(neuron.URL)
+
+	tests := []struct {
+		name       string
+		offline    bool
+		wantStatus int
+	}{
+		{"offline", true, http.StatusOK},
+		// online the tests are forwarded to neuron and nothing is written
+		{"online", false, http.StatusAccepted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.NucleusConfig{Offline: tt.offline, OfflineDir: filepath.Join(t.TempDir(), "offline")}
+			router := NewRouter(cfg, logger, &teststats.ProcStats{}).Handler()
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/test-list", strings.NewReader(body))
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tt.wantStatus, w.Code)
+
+			if tt.offline {
+				got, err := ioutil.ReadFile(filepath.Join(cfg.OfflineDir, global.OfflineTestListFile))
+				if err != nil {
+					t.Fatalf("failed to read test list: %v", err)
+				}
+				assert.Equal(t, body, string(got))
+			} else {
+				assert.NoFileExists(t, filepath.Join(cfg.OfflineDir, global.OfflineTestListFile))
+			}
+		})
+	}
+}
+
+func TestDiscoveryHook(t *testing.T) {
+	logger, err := lumber.NewLogger(lumber.LoggingConfig{EnableConsole: true}, true, lumber.InstanceZapLogger)
+	if err != nil {
+		log.Fatalf("Could not instantiate logger %s", err.Error())
+	}
+	body := `{"tests": [{"title": "adds two numbers"}]}`
+
+	var received string
+	neuron := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ := ioutil.ReadAll(r.Body)
+		received = string(got)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer neuron.Close()
+	defer global.SetNeuronHost(global.NeuronHost)
+	global.SetNeuronHost(neuron.URL)
+
+	tests := []struct {
+		name       string
+		hook       string
+		wantStatus int
+		want       string
+	}{
+		{"identity", "cat", http.StatusOK, body},
+		{"mutating", `sed 's/adds two numbers/sums two numbers/'`, http.StatusOK, `{"tests": [{"title": "sums two numbers"}]}`},
+		{"invalid json", "echo 'not json'", http.StatusUnprocessableEntity, ""},
+		{"different kind", "echo '[]'", http.StatusUnprocessableEntity, ""},
+		{"failing hook", "cat > /dev/null; exit 1", http.StatusUnprocessableEntity, ""},
+	}
+	for _, offline := range []bool{true, false} {
+		for _, tt := range tests {
+			t.Run(fmt.Sprintf("%s offline %t", tt.name, offline), func(t *testing.T) {
+				received = ""
+				cfg := &config.NucleusConfig{Offline: offline, OfflineDir: filepath.Join(t.TempDir(), "offline"), DiscoveryHook: tt.hook}
+				router := NewRouter(cfg, logger, &teststats.ProcStats{}).Handler()
+
+				w := httptest.NewRecorder()
+				req := httptest.NewRequest(http.MethodPost, "/test-list", strings.NewReader(body))
+				router.ServeHTTP(w, req)
+				assert.Equal(t, tt.wantStatus, w.Code)
+
+				got := received
+				if offline {
+					data, _ := ioutil.ReadFile(filepath.Join(cfg.OfflineDir, global.OfflineTestListFile))
+					got = string(data)
+				}
+				// an invalid result is neither written nor posted
+				assert.Equal(t, tt.want, got)
+			})
+		}
+	}
+}
+
+func TestTestListOrder(t *testing.T) {
+	logger, err := lumber.NewLogger(lumber.LoggingConfig{EnableConsole: true}, true, lumber.InstanceZapLogger)
+	if err != nil {
+		log.Fatalf("Could not instantiate logger %s", err.Error())
+	}
+	add := `{"file":"src/add.test.js","title":"adds two numbers"}`
+	addZero := `{"file":"src/add.test.js","title":"adds zero"}`
+	sub := `{"file":"src/sub.test.js","title":"subtracts two numbers"}`
+	want := fmt.Sprintf(`{"tests":[%s,%s,%s]}`, add, addZero, sub)
+
+	var received string
+	neuron := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ := ioutil.ReadAll(r.Body)
+		received = string(got)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer neuron.Close()
+	defer global.SetNeuronHost(global.NeuronHost)
+	global.SetNeuronHost(neuron.URL)
+
+	// the same tests discovered in a different order on each run
+	runs := [][]string{
+		{sub, addZero, add},
+		{addZero, sub, add},
+		{add, addZero, sub},
+	}
+	for _, offline := range []bool{true, false} {
+		for i, run := range runs {
+			t.Run(fmt.Sprintf("run %d offline %t", i, offline), func(t *testing.T) {
+				received = ""
+				cfg := &config.NucleusConfig{Offline: offline, OfflineDir: filepath.Join(t.TempDir(), "offline")}
+				router := NewRouter(cfg, logger, &teststats.ProcStats{}).Handler()
+
+				body := fmt.Sprintf(`{"tests":[%s]}`, strings.Join(run, ","))
+				w := httptest.NewRecorder()
+				req := httptest.NewRequest(http.MethodPost, "/test-list", strings.NewReader(body))
+				router.ServeHTTP(w, req)
+				assert.Equal(t, http.StatusOK, w.Code)
+
+				got := received
+				if offline {
+					data, _ := ioutil.ReadFile(filepath.Join(cfg.OfflineDir, global.OfflineTestListFile))
+					got = string(data)
+				}
+				assert.Equal(t, want, got)
+			})
+		}
+	}
+}
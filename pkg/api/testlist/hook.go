@@ -0,0 +1,48 @@
+package testlist
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+
+	"github.com/LambdaTest/synapse/pkg/errs"
+)
+
+// transform runs the hook with the discovery result on stdin and returns the result printed on stdout.
+// The result must be json of the same kind, object or array, as the discovery result.
+func transform(ctx context.Context, hook string, result []byte) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "/bin/bash", "-c", hook)
+	cmd.Stdin = bytes.NewReader(result)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%w: %v: %s", errs.ErrDiscoveryHook, err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	transformed := bytes.TrimSpace(stdout.Bytes())
+	if !json.Valid(transformed) {
+		return nil, fmt.Errorf("%w: output is not valid json", errs.ErrDiscoveryHook)
+	}
+	if want, got := jsonKind(result), jsonKind(transformed); want != got {
+		return nil, fmt.Errorf("%w: output is a json %s instead of a json %s", errs.ErrDiscoveryHook, got, want)
+	}
+	return transformed, nil
+}
+
+// jsonKind returns the kind of the json value
+func jsonKind(data []byte) string {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return "value"
+	}
+	switch data[0] {
+	case '{':
+		return "object"
+	case '[':
+		return "array"
+	default:
+		return "value"
+	}
+}
@@ -0,0 +1,61 @@
+package testlist
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// testKey is the part of a discovered test the tests are ordered by
+type testKey struct {
+	FilePath string `json:"file"`
+	Title    string `json:"title"`
+}
+
+type discoveredTest struct {
+	key testKey
+	raw json.RawMessage
+}
+
+// sortTests orders the tests of the discovery result by file path and then by title, so that the same
+// tests are posted in the same order whatever order the runner discovered them in.
+// The result is returned as is if it has no list of tests or the tests are already in order.
+func sortTests(result []byte) []byte {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(result, &fields); err != nil || fields["tests"] == nil {
+		return result
+	}
+	var raw []json.RawMessage
+	if err := json.Unmarshal(fields["tests"], &raw); err != nil {
+		return result
+	}
+	tests := make([]discoveredTest, len(raw))
+	for i := range raw {
+		if err := json.Unmarshal(raw[i], &tests[i].key); err != nil {
+			return result
+		}
+		tests[i].raw = raw[i]
+	}
+	less := func(i, j int) bool {
+		if tests[i].key.FilePath != tests[j].key.FilePath {
+			return tests[i].key.FilePath < tests[j].key.FilePath
+		}
+		return tests[i].key.Title < tests[j].key.Title
+	}
+	if sort.SliceIsSorted(tests, less) {
+		return result
+	}
+	sort.SliceStable(tests, less)
+	for i := range tests {
+		raw[i] = tests[i].raw
+	}
+	sorted, err := json.Marshal(raw)
+	if err != nil {
+		return result
+	}
+	fields["tests"] = sorted
+	body, err := json.Marshal(fields)
+	if err != nil {
+		return result
+	}
+	return body
+}
@@ -0,0 +1,91 @@
+package testlist
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/LambdaTest/synapse/pkg/global"
+	"github.com/LambdaTest/synapse/pkg/lumber"
+	"github.com/LambdaTest/synapse/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// Handler captures the discovered tests posted by the runner in offline mode and writes them to dir,
+// after passing them through the discovery hook if there is one
+func Handler(logger lumber.Logger, dir, hook string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		body, ok := readBody(c, logger, hook)
+		if !ok {
+			return
+		}
+		if err := utils.CreateDirectory(dir); err != nil {
+			logger.Errorf("error while creating directory %s %v", dir, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			return
+		}
+		if err := utils.WriteFileToDirectory(dir, global.OfflineTestListFile, body); err != nil {
+			logger.Errorf("error while writing test list %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			return
+		}
+		c.Data(http.StatusOK, gin.MIMEPlain, []byte(http.StatusText(http.StatusOK)))
+	}
+}
+
+// ForwardHandler passes the discovered tests posted by the runner through the discovery hook if there is one
+// and posts the result to endpoint, the response of the endpoint is relayed to the runner
+func ForwardHandler(logger lumber.Logger, hook, endpoint string) gin.HandlerFunc {
+	client := http.Client{Timeout: global.DefaultHTTPTimeout}
+	return func(c *gin.Context) {
+		body, ok := readBody(c, logger, hook)
+		if !ok {
+			return
+		}
+		req, err := http.NewRequestWithContext(c.Request.Context(), http.MethodPost, endpoint, bytes.NewReader(body))
+		if err != nil {
+			logger.Errorf("error while creating request %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			return
+		}
+		req.Header.Set("Content-Type", "application/json")
+		utils.AddNeuronHeaders(req)
+		resp, err := client.Do(req)
+		if err != nil {
+			logger.Errorf("error while posting test list to %s %v", endpoint, err)
+			c.JSON(http.StatusBadGateway, gin.H{"message": err.Error()})
+			return
+		}
+		defer resp.Body.Close()
+		respBody, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			logger.Errorf("error while reading response of %s %v", endpoint, err)
+			c.JSON(http.StatusBadGateway, gin.H{"message": err.Error()})
+			return
+		}
+		c.Data(resp.StatusCode, resp.Header.Get("Content-Type"), respBody)
+	}
+}
+
+// readBody returns the discovered tests of the request sorted by file path and title,
+// and transformed by the hook if there is one.
+// ok is false if the request has been answered with an error.
+func readBody(c *gin.Context, logger lumber.Logger, hook string) (body []byte, ok bool) {
+	body, err := ioutil.ReadAll(c.Request.Body)
+	if err != nil {
+		logger.Errorf("error while reading request body %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return nil, false
+	}
+	body = sortTests(body)
+	if hook == "" {
+		return body, true
+	}
+	body, err = transform(c.Request.Context(), hook, body)
+	if err != nil {
+		logger.Errorf("error while transforming test list %v", err)
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error()})
+		return nil, false
+	}
+	return body, true
+}
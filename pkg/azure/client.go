@@ -18,6 +18,7 @@ import (
 	"github.com/LambdaTest/synapse/pkg/errs"
 	"github.com/LambdaTest/synapse/pkg/global"
 	"github.com/LambdaTest/synapse/pkg/lumber"
+	"github.com/LambdaTest/synapse/pkg/utils"
 )
 
 var (
@@ -103,6 +104,33 @@ func (s *Store) FindUsingSASUrl(ctx context.Context, sasURL string) (io.ReadClos
 	return out.Body(azblob.RetryReaderOptions{MaxRetryRequests: 5}), nil
 }
 
+// FindRangeUsingSASUrl downloads object based on sasURL from offset, if it still matches the etag.
+// The bytes before offset are skipped if the range is not honoured.
+func (s *Store) FindRangeUsingSASUrl(ctx context.Context, sasURL string, offset int64, etag string) (io.ReadCloser, string, error) {
+	u, err := url.Parse(sasURL)
+	if err != nil {
+		return nil, "", err
+	}
+	blobURL := azblob.NewBlobURL(*u, azblob.NewPipeline(azblob.NewAnonymousCredential(), azblob.PipelineOptions{}))
+
+	conditions := azblob.BlobAccessConditions{}
+	if etag != "" {
+		conditions.ModifiedAccessConditions.IfMatch = azblob.ETag(etag)
+	}
+	out, err := blobURL.Download(ctx, offset, azblob.CountToEnd, conditions, false, azblob.ClientProvidedKeyOptions{})
+	if err != nil {
+		return nil, "", handleError(err)
+	}
+	body := out.Body(azblob.RetryReaderOptions{MaxRetryRequests: 5})
+	if offset > 0 && out.StatusCode() != http.StatusPartialContent {
+		if _, err := io.CopyN(ioutil.Discard, body, offset); err != nil {
+			body.Close()
+			return nil, "", err
+		}
+	}
+	return body, string(out.ETag()), nil
+}
+
 // CreateUsingSASURL creates object using sasURL
 func (s *Store) CreateUsingSASURL(ctx context.Context, sasURL string, reader io.Reader, mimeType string) (string, error) {
 	u, err := url.Parse(sasURL)
@@ -157,6 +185,7 @@ func (s *Store) GetSASURL(ctx context.Context, containerPath string, containerTy
 		s.logger.Errorf("error while creating http request, error %v", err)
 		return "", err
 	}
+	utils.AddNeuronHeaders(req)
 	resp, err := s.httpClient.Do(req)
 	if err != nil {
 		s.logger.Errorf("error while getting SAS URL, error %v", err)
@@ -201,6 +230,8 @@ func handleError(err error) error {
 		switch serr.ServiceCode() { // Compare serviceCode to ServiceCodeXxx constants
 		case azblob.ServiceCodeBlobNotFound:
 			return errs.ErrNotFound
+		case azblob.ServiceCodeConditionNotMet, azblob.ServiceCodeInvalidRange:
+			return errs.ErrBlobChanged
 		}
 	}
 	return err
@@ -4,11 +4,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"io"
 	"os"
 	"path/filepath"
 	"sync"
+	"time"
 
+	"github.com/LambdaTest/synapse/config"
 	"github.com/LambdaTest/synapse/pkg/core"
 	"github.com/LambdaTest/synapse/pkg/errs"
 	"github.com/LambdaTest/synapse/pkg/fileutils"
@@ -33,13 +34,26 @@ type cache struct {
 	zstd        core.ZstdCompressor
 	skipUpload  bool
 	homeDir     string
+	tempDir     string
+	repoDir     string
+	timeout     time.Duration
+	// baseKey is the key of the shared base cache which is overlaid with the cache of the repo
+	baseKey string
+	// snapshot is the state of the cached files after restoring the base cache
+	snapshot map[string]fileState
+	// workspaceLimit is the maximum size in bytes of the files in the workspace snapshot
+	workspaceLimit int64
+	// extractRetries is the number of times the cache is downloaded again when its extraction fails
+	extractRetries int
+	// verify enables the checksum manifest of the cache
+	verify bool
 }
 
 var cacheBlobURL string
 var apiErr error
 
 // New returns a new CacheStore
-func New(z core.ZstdCompressor, azureClient core.AzureClient, logger lumber.Logger) (core.CacheStore, error) {
+func New(z core.ZstdCompressor, azureClient core.AzureClient, cfg *config.NucleusConfig, logger lumber.Logger) (core.CacheStore, error) {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		return nil, err
@@ -49,9 +63,35 @@ func New(z core.ZstdCompressor, azureClient core.AzureClient, logger lumber.Logg
 		zstd:        z,
 		logger:      logger,
 		homeDir:     homeDir,
+		tempDir:     cfg.TempDir,
+		repoDir:     global.RepoDir,
+		timeout:     time.Duration(cfg.CacheTimeout) * time.Second,
+		baseKey:     cfg.CacheBaseKey,
+		// the limit is in MB
+		workspaceLimit: int64(cfg.WorkspaceSnapshotLimit) * global.MB,
+		extractRetries: cfg.CacheExtractRetries,
+		verify:         cfg.VerifyCache,
 	}, nil
 }
 
+// withTimeout derives a context which expires after the configured cache timeout.
+// A non-positive timeout disables the deadline.
+func (c *cache) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, c.timeout)
+}
+
+// wrapTimeoutErr returns ErrCacheTimeout if the operation failed because the cache timeout expired.
+func (c *cache) wrapTimeoutErr(ctx context.Context, err error) error {
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		c.logger.Errorf("cache operation exceeded timeout of %s, error %v", c.timeout, err)
+		return errs.ErrCacheTimeout
+	}
+	return err
+}
+
 func (c *cache) getCacheSASURL(ctx context.Context, containerPath string) (string, error) {
 	c.once.Do(func() {
 		cacheBlobURL, apiErr = c.azureClient.GetSASURL(ctx, containerPath, core.CacheContainer)
@@ -59,58 +99,120 @@ func (c *cache) getCacheSASURL(ctx context.Context, containerPath string) (strin
 	return cacheBlobURL, apiErr
 }
 
-func (c *cache) Download(ctx context.Context, cacheKey string) error {
+func (c *cache) Download(ctx context.Context, cacheKey string, itemsToCache ...string) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	return c.wrapTimeoutErr(ctx, c.download(ctx, cacheKey, itemsToCache...))
+}
+
+func (c *cache) download(ctx context.Context, cacheKey string, itemsToCache ...string) error {
+	if c.baseKey != "" {
+		if err := c.restoreBase(ctx, itemsToCache...); err != nil {
+			return err
+		}
+	}
 	containerPath := fmt.Sprintf("%s/%s", cacheKey, defaultCompressedFileName)
 	sasURL, err := c.getCacheSASURL(ctx, containerPath)
 	if err != nil {
 		c.logger.Errorf("Error while generating SAS Token, error %v", err)
 		return err
 	}
-	resp, err := c.azureClient.FindUsingSASUrl(ctx, sasURL)
-	if err != nil {
-		if errors.Is(err, errs.ErrNotFound) {
-			c.logger.Infof("Cache not found for key: %s", cacheKey)
-			return nil
-		}
-		c.logger.Errorf("Error while downloading cache for key: %s, error %v", cacheKey, err)
+	found, err := c.fetch(ctx, sasURL, cacheKey)
+	if err != nil || !found {
 		return err
 	}
 	c.skipUpload = true
-	defer resp.Close()
-
-	cachedFilePath := filepath.Join(os.TempDir(), defaultCompressedFileName)
-	out, err := os.Create(cachedFilePath)
-	if err != nil {
-		return err
+	if c.baseKey != "" {
+		return c.applyDeletions()
 	}
-	defer out.Close()
+	return nil
+}
 
-	if _, err := io.Copy(out, resp); err != nil {
-		return err
+// fetch downloads the cache at the SAS URL and decompresses it in the repo directory,
+// found is false if there is no such cache. A cache which fails to decompress is downloaded
+// again up to the configured number of retries.
+func (c *cache) fetch(ctx context.Context, sasURL, cacheKey string) (found bool, err error) {
+	cachedFilePath := filepath.Join(c.tempDir, defaultCompressedFileName)
+	for retry := 0; ; retry++ {
+		found, err = c.fetchFile(ctx, sasURL, cacheKey, cachedFilePath)
+		if err != nil || !found {
+			return found, err
+		}
+		if c.verify {
+			hasManifest, err := c.hasManifest(ctx, cachedFilePath)
+			if err != nil {
+				return false, err
+			}
+			// caches written before the verification was enabled are replaced by the upload
+			if !hasManifest {
+				c.logger.Warnf("Cache for key: %s has no checksum manifest, ignoring it", cacheKey)
+				return false, nil
+			}
+		}
+		//decompress
+		err = c.zstd.Decompress(ctx, cachedFilePath, true, c.repoDir)
+		if err == nil {
+			// a corrupt cache is not downloaded again, it was corrupted before the upload
+			return true, c.checkManifest()
+		}
+		if ctx.Err() != nil {
+			return false, err
+		}
+		if retry >= c.extractRetries {
+			c.logger.Errorf("Error while extracting cache for key: %s, error %v", cacheKey, err)
+			return false, fmt.Errorf("%w after %d attempts: %v", errs.ErrCacheExtraction, retry+1, err)
+		}
+		c.logger.Warnf("Extraction of cache for key: %s failed, downloading it again, error %v", cacheKey, err)
+		// the archive may be corrupt
+		if err := os.Remove(cachedFilePath); err != nil {
+			return false, err
+		}
 	}
-	//decompress
-	return c.zstd.Decompress(ctx, cachedFilePath, true, global.RepoDir)
+}
 
+// fetchFile downloads the cache at the SAS URL to path, found is false if there is no such cache.
+// Interrupted downloads are resumed.
+func (c *cache) fetchFile(ctx context.Context, sasURL, cacheKey, cachedFilePath string) (found bool, err error) {
+	for attempt := 1; ; attempt++ {
+		err = c.downloadFile(ctx, sasURL, cacheKey, cachedFilePath)
+		if err == nil {
+			break
+		}
+		if errors.Is(err, errs.ErrNotFound) {
+			c.logger.Infof("Cache not found for key: %s", cacheKey)
+			return false, nil
+		}
+		// the checkpoint is kept when giving up, so that a later download resumes
+		if ctx.Err() != nil || attempt == maxDownloadAttempts {
+			c.logger.Errorf("Error while downloading cache for key: %s, error %v", cacheKey, err)
+			return false, err
+		}
+		c.logger.Warnf("Download of cache for key: %s interrupted, resuming, error %v", cacheKey, err)
+	}
+	return true, nil
 }
 
 func (c *cache) Upload(ctx context.Context, cacheKey string, itemsToCompress ...string) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	return c.wrapTimeoutErr(ctx, c.upload(ctx, cacheKey, itemsToCompress...))
+}
+
+func (c *cache) upload(ctx context.Context, cacheKey string, itemsToCompress ...string) error {
 	if c.skipUpload {
 		c.logger.Infof("Cache hit occurred on the key %s, not saving cache.", cacheKey)
 		return nil
 	}
 
 	validatedItems := make([]string, 0, len(itemsToCompress))
-	if len(itemsToCompress) == 0 {
-		dir, err := c.getDefaultDirs()
-		if err != nil {
-			c.logger.Errorf("failed to get default cache directories, error %v", err)
-			return nil
-		}
-		itemsToCompress = append(itemsToCompress, dir)
+	itemsToCompress, err := c.cacheItems(itemsToCompress)
+	if err != nil {
+		c.logger.Errorf("failed to get default cache directories, error %v", err)
+		return nil
 	}
 	// validate the file or dir paths if it exists.
 	for _, item := range itemsToCompress {
-		exists, err := fileutils.CheckIfExists(item)
+		exists, err := fileutils.CheckIfExists(c.absPath(item))
 		if err != nil {
 			return err
 		}
@@ -125,13 +227,32 @@ func (c *cache) Upload(ctx context.Context, cacheKey string, itemsToCompress ...
 		return nil
 	}
 
-	err := c.zstd.Compress(ctx, defaultCompressedFileName, true, global.RepoDir, validatedItems...)
+	if c.baseKey != "" {
+		deltaItems, err := c.deltaItems(validatedItems)
+		if err != nil {
+			c.logger.Errorf("failed to compute the changes to the base cache %s, error: %v", c.baseKey, err)
+			return err
+		}
+		defer os.Remove(filepath.Join(c.repoDir, deletedFilesName))
+		validatedItems = deltaItems
+	}
+	if c.verify {
+		if err := c.writeManifest(validatedItems); err != nil {
+			c.logger.Errorf("failed to write the checksum manifest of the cache with key %s, error: %v", cacheKey, err)
+			return err
+		}
+		defer os.Remove(filepath.Join(c.repoDir, manifestName))
+		validatedItems = append(validatedItems, manifestName)
+	}
+
+	compressedFilePath := filepath.Join(c.repoDir, defaultCompressedFileName)
+	err = c.zstd.Compress(ctx, compressedFilePath, true, c.repoDir, validatedItems...)
 	if err != nil {
 		c.logger.Errorf("error while compressing files with key %s, error: %v", cacheKey, err)
 		return err
 	}
 
-	f, err := os.Open(filepath.Join(global.RepoDir, defaultCompressedFileName))
+	f, err := os.Open(compressedFilePath)
 	if err != nil {
 		c.logger.Errorf("error while opening compressed file with key %s, error: %v", cacheKey, err)
 		return err
@@ -152,8 +273,20 @@ func (c *cache) Upload(ctx context.Context, cacheKey string, itemsToCompress ...
 	return nil
 }
 
+// cacheItems returns the items to cache, which default to the cache directory of the package manager
+func (c *cache) cacheItems(items []string) ([]string, error) {
+	if len(items) > 0 {
+		return items, nil
+	}
+	dir, err := c.getDefaultDirs()
+	if err != nil {
+		return nil, err
+	}
+	return []string{dir}, nil
+}
+
 func (c *cache) getDefaultDirs() (string, error) {
-	f, err := os.Open(global.RepoDir)
+	f, err := os.Open(c.repoDir)
 	if err != nil {
 		return "", err
 	}
@@ -0,0 +1,125 @@
+package cachemanager
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/LambdaTest/synapse/pkg/core"
+	"github.com/LambdaTest/synapse/pkg/errs"
+	"github.com/LambdaTest/synapse/pkg/lumber"
+	"github.com/stretchr/testify/assert"
+)
+
+// slowAzureClient blocks on every blob operation until the context is done
+type slowAzureClient struct{}
+
+func (s *slowAzureClient) FindUsingSASUrl(ctx context.Context, sasURL string) (io.ReadCloser, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (s *slowAzureClient) FindRangeUsingSASUrl(ctx context.Context, sasURL string, offset int64, etag string) (io.ReadCloser, string, error) {
+	<-ctx.Done()
+	return nil, "", ctx.Err()
+}
+
+func (s *slowAzureClient) Find(ctx context.Context, path string) (io.ReadCloser, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (s *slowAzureClient) Create(ctx context.Context, path string, reader io.Reader, mimeType string) (string, error) {
+	<-ctx.Done()
+	return "", ctx.Err()
+}
+
+func (s *slowAzureClient) CreateUsingSASURL(ctx context.Context, sasURL string, reader io.Reader, mimeType string) (string, error) {
+	<-ctx.Done()
+	return "", ctx.Err()
+}
+
+func (s *slowAzureClient) GetSASURL(ctx context.Context, containerPath string, containerType core.ContainerType) (string, error) {
+	return "https://dummy.blob.core.windows.net/cache/" + containerPath, nil
+}
+
+func (s *slowAzureClient) Exists(ctx context.Context, path string) (bool, error) {
+	<-ctx.Done()
+	return false, ctx.Err()
+}
+
+func TestDownloadTimeout(t *testing.T) {
+	logger, err := lumber.NewLogger(lumber.LoggingConfig{EnableConsole: true}, true, lumber.InstanceZapLogger)
+	if err != nil {
+		t.Fatalf("Could not instantiate logger %s", err.Error())
+	}
+	c := &cache{azureClient: &slowAzureClient{}, logger: logger, timeout: 50 * time.Millisecond}
+
+	start := time.Now()
+	err = c.Download(context.Background(), "org/repo/key")
+	assert.Equal(t, errs.ErrCacheTimeout, err)
+	assert.Less(t, int64(time.Since(start)), int64(5*time.Second))
+}
+
+func TestDownloadCanceled(t *testing.T) {
+	logger, err := lumber.NewLogger(lumber.LoggingConfig{EnableConsole: true}, true, lumber.InstanceZapLogger)
+	if err != nil {
+		t.Fatalf("Could not instantiate logger %s", err.Error())
+	}
+	c := &cache{azureClient: &slowAzureClient{}, logger: logger, timeout: time.Minute}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = c.Download(ctx, "org/repo/key")
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+// flakyCompressor fails the first failures decompressions
+type flakyCompressor struct {
+	core.ZstdCompressor
+	failures int
+	calls    int
+}
+
+func (f *flakyCompressor) Decompress(ctx context.Context, filePath string, preservePath bool, workingDirectory string, filesToDecompress ...string) error {
+	f.calls++
+	if f.calls <= f.failures {
+		return errors.New("unexpected EOF")
+	}
+	return nil
+}
+
+func TestDownloadExtractRetry(t *testing.T) {
+	logger, err := lumber.NewLogger(lumber.LoggingConfig{EnableConsole: true}, true, lumber.InstanceZapLogger)
+	if err != nil {
+		t.Fatalf("Could not instantiate logger %s", err.Error())
+	}
+	tests := []struct {
+		name        string
+		failures    int
+		wantErr     error
+		wantOffsets []int64
+	}{
+		{"succeeds", 0, nil, []int64{0}},
+		{"fails once then succeeds", 1, nil, []int64{0, 0}},
+		{"fails repeatedly", 2, errs.ErrCacheExtraction, []int64{0, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			azureClient := &interruptingAzureClient{blob: []byte("archive"), etag: "v1"}
+			z := &flakyCompressor{failures: tt.failures}
+			c := &cache{azureClient: azureClient, zstd: z, logger: logger, tempDir: t.TempDir(), timeout: time.Minute, extractRetries: 1}
+			err := c.Download(context.Background(), "org/repo/key")
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.Nil(t, err)
+			}
+			// the cache is downloaded again for every extraction
+			assert.Equal(t, tt.wantOffsets, azureClient.offsets)
+			assert.Equal(t, c.skipUpload, tt.wantErr == nil)
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package cachemanager
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/LambdaTest/synapse/pkg/core"
+)
+
+// deletedFilesName is the file of the repo cache listing the files of the base cache which were
+// removed, as an archive can not express removals
+const deletedFilesName = ".tas-cache-deleted"
+
+// fileState identifies the version of a cached file
+type fileState struct {
+	size    int64
+	mode    os.FileMode
+	modTime int64
+}
+
+// restoreBase restores the shared base cache and snapshots the cached items, so that the cache of
+// the repo only holds the files which differ from the base. The base cache is read-only, a new
+// baseline is published under a new key.
+func (c *cache) restoreBase(ctx context.Context, itemsToCache ...string) error {
+	containerPath := fmt.Sprintf("%s/%s", c.baseKey, defaultCompressedFileName)
+	sasURL, err := c.azureClient.GetSASURL(ctx, containerPath, core.CacheContainer)
+	if err != nil {
+		c.logger.Errorf("Error while generating SAS Token for base cache, error %v", err)
+		return err
+	}
+	if _, err := c.fetch(ctx, sasURL, c.baseKey); err != nil {
+		return err
+	}
+	items, err := c.cacheItems(itemsToCache)
+	if err != nil {
+		return err
+	}
+	c.snapshot, err = c.walkItems(items)
+	return err
+}
+
+// deltaItems returns the files of the items which were added or changed since the base cache
+// was restored, along with the file listing the removed files of the base cache
+func (c *cache) deltaItems(items []string) ([]string, error) {
+	current, err := c.walkItems(items)
+	if err != nil {
+		return nil, err
+	}
+	var changed, deleted []string
+	for path, state := range current {
+		if base, ok := c.snapshot[path]; !ok || base != state {
+			changed = append(changed, path)
+		}
+	}
+	for path := range c.snapshot {
+		if _, ok := current[path]; !ok {
+			deleted = append(deleted, path)
+		}
+	}
+	sort.Strings(changed)
+	sort.Strings(deleted)
+	c.logger.Debugf("%d files changed and %d files removed since base cache %s", len(changed), len(deleted), c.baseKey)
+
+	var content string
+	if len(deleted) > 0 {
+		content = strings.Join(deleted, "\n") + "\n"
+	}
+	if err := ioutil.WriteFile(filepath.Join(c.repoDir, deletedFilesName), []byte(content), 0644); err != nil {
+		return nil, err
+	}
+	return append(changed, deletedFilesName), nil
+}
+
+// applyDeletions removes the files of the base cache which the cache of the repo lists as removed.
+// Only files restored from the base cache are removed.
+func (c *cache) applyDeletions() error {
+	listPath := filepath.Join(c.repoDir, deletedFilesName)
+	f, err := os.Open(listPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer os.Remove(listPath)
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		path := scanner.Text()
+		if _, ok := c.snapshot[path]; !ok {
+			continue
+		}
+		if err := os.Remove(c.absPath(path)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// walkItems returns the state of the files within the items, keyed by their path in the archive
+func (c *cache) walkItems(items []string) (map[string]fileState, error) {
+	files := make(map[string]fileState)
+	for _, item := range items {
+		root := c.absPath(item)
+		err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				if path == root && os.IsNotExist(err) {
+					return nil
+				}
+				return err
+			}
+			if info.IsDir() {
+				return nil
+			}
+			rel, err := filepath.Rel(root, path)
+			if err != nil {
+				return err
+			}
+			files[filepath.Join(item, rel)] = fileState{size: info.Size(), mode: info.Mode(), modTime: info.ModTime().UnixNano()}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// absPath resolves the path of a cached item, relative paths are within the repo directory
+func (c *cache) absPath(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(c.repoDir, path)
+}
@@ -0,0 +1,266 @@
+package cachemanager
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/LambdaTest/synapse/pkg/core"
+	"github.com/LambdaTest/synapse/pkg/errs"
+	"github.com/LambdaTest/synapse/pkg/lumber"
+	"github.com/stretchr/testify/assert"
+)
+
+// memAzureClient keeps the blobs in memory, keyed by their SAS URL
+type memAzureClient struct {
+	slowAzureClient
+	blobs map[string][]byte
+}
+
+func (m *memAzureClient) FindUsingSASUrl(ctx context.Context, sasURL string) (io.ReadCloser, error) {
+	blob, ok := m.blobs[sasURL]
+	if !ok {
+		return nil, errs.ErrNotFound
+	}
+	return ioutil.NopCloser(bytes.NewReader(blob)), nil
+}
+
+func (m *memAzureClient) FindRangeUsingSASUrl(ctx context.Context, sasURL string, offset int64, etag string) (io.ReadCloser, string, error) {
+	body, err := m.FindUsingSASUrl(ctx, sasURL)
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err := io.CopyN(ioutil.Discard, body, offset); err != nil {
+		return nil, "", err
+	}
+	return body, "", nil
+}
+
+func (m *memAzureClient) Create(ctx context.Context, path string, reader io.Reader, mimeType string) (string, error) {
+	return m.CreateUsingSASURL(ctx, path, reader, mimeType)
+}
+
+func (m *memAzureClient) CreateUsingSASURL(ctx context.Context, sasURL string, reader io.Reader, mimeType string) (string, error) {
+	blob, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return "", err
+	}
+	m.blobs[sasURL] = blob
+	return sasURL, nil
+}
+
+// tarCompressor archives the files with archive/tar instead of running tar and zstd
+type tarCompressor struct {
+	// archived records the files of the last archive
+	archived []string
+}
+
+func (z *tarCompressor) Compress(ctx context.Context, compressedFileName string, preservePath bool, workingDirectory string, filesToCompress ...string) error {
+	out, err := os.Create(compressedFileName)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	tw := tar.NewWriter(out)
+	z.archived = nil
+	for _, name := range filesToCompress {
+		err := filepath.Walk(filepath.Join(workingDirectory, name), func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return err
+			}
+			rel, err := filepath.Rel(workingDirectory, path)
+			if err != nil {
+				return err
+			}
+			hdr, err := tar.FileInfoHeader(info, "")
+			if err != nil {
+				return err
+			}
+			hdr.Name = rel
+			if err := tw.WriteHeader(hdr); err != nil {
+				return err
+			}
+			content, err := ioutil.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			z.archived = append(z.archived, rel)
+			_, err = tw.Write(content)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
+
+func (z *tarCompressor) Decompress(ctx context.Context, filePath string, preservePath bool, workingDirectory string, filesToDecompress ...string) error {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	tr := tar.NewReader(f)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if len(filesToDecompress) != 0 && !contains(filesToDecompress, hdr.Name) {
+			continue
+		}
+		path := filepath.Join(workingDirectory, hdr.Name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		content, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(path, content, hdr.FileInfo().Mode()); err != nil {
+			return err
+		}
+		if err := os.Chtimes(path, hdr.ModTime, hdr.ModTime); err != nil {
+			return err
+		}
+	}
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+	}
+}
+
+func readFiles(t *testing.T, dir string) map[string]string {
+	files := make(map[string]string)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, path)
+		files[rel] = string(content)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to read files: %v", err)
+	}
+	return files
+}
+
+func TestBaseCacheRestore(t *testing.T) {
+	logger, err := lumber.NewLogger(lumber.LoggingConfig{EnableConsole: true}, true, lumber.InstanceZapLogger)
+	if err != nil {
+		t.Fatalf("Could not instantiate logger %s", err.Error())
+	}
+	azureClient := &memAzureClient{blobs: make(map[string][]byte)}
+	z := &tarCompressor{}
+	newCache := func() *cache {
+		return &cache{
+			azureClient: azureClient,
+			zstd:        z,
+			logger:      logger,
+			tempDir:     t.TempDir(),
+			repoDir:     t.TempDir(),
+			timeout:     time.Minute,
+			baseKey:     "org/base/v1",
+		}
+	}
+
+	// publish the shared base cache
+	baseDir := t.TempDir()
+	writeFiles(t, baseDir, map[string]string{
+		"node_modules/a/index.js": "a v1",
+		"node_modules/b/index.js": "b v1",
+		"node_modules/d/index.js": "d v1",
+	})
+	baseArchive := filepath.Join(t.TempDir(), defaultCompressedFileName)
+	if err := z.Compress(context.TODO(), baseArchive, true, baseDir, "node_modules"); err != nil {
+		t.Fatalf("failed to create base cache: %v", err)
+	}
+	baseBlob, err := ioutil.ReadFile(baseArchive)
+	if err != nil {
+		t.Fatalf("failed to read base cache: %v", err)
+	}
+	baseURL, _ := azureClient.GetSASURL(context.TODO(), "org/base/v1/"+defaultCompressedFileName, core.CacheContainer)
+	azureClient.blobs[baseURL] = baseBlob
+
+	// the first build restores the base only, changes the dependencies and uploads the delta
+	first := newCache()
+	assert.Nil(t, first.Download(context.TODO(), "org/repo/v1", "node_modules"))
+	assert.False(t, first.skipUpload)
+	writeFiles(t, first.repoDir, map[string]string{
+		"node_modules/a/index.js": "a v2",
+		"node_modules/c/index.js": "c v1",
+	})
+	assert.Nil(t, os.Remove(filepath.Join(first.repoDir, "node_modules/b/index.js")))
+	want := readFiles(t, first.repoDir)
+	assert.Nil(t, first.Upload(context.TODO(), "org/repo/v1", "node_modules"))
+	// the unchanged files of the base are not uploaded again
+	assert.Equal(t, []string{"node_modules/a/index.js", "node_modules/c/index.js", deletedFilesName}, z.archived)
+
+	// the next build overlays the delta on the base
+	second := newCache()
+	assert.Nil(t, second.Download(context.TODO(), "org/repo/v1", "node_modules"))
+	assert.True(t, second.skipUpload)
+	assert.Equal(t, want, readFiles(t, second.repoDir))
+}
+
+func TestUploadWorkspace(t *testing.T) {
+	logger, err := lumber.NewLogger(lumber.LoggingConfig{EnableConsole: true}, true, lumber.InstanceZapLogger)
+	if err != nil {
+		t.Fatalf("Could not instantiate logger %s", err.Error())
+	}
+	azureClient := &memAzureClient{blobs: make(map[string][]byte)}
+	z := &tarCompressor{}
+	c := &cache{
+		azureClient:    azureClient,
+		zstd:           z,
+		logger:         logger,
+		tempDir:        t.TempDir(),
+		repoDir:        t.TempDir(),
+		timeout:        time.Minute,
+		workspaceLimit: 10,
+	}
+	writeFiles(t, c.repoDir, map[string]string{
+		"src/index.js":            "12345",
+		"src/large.js":            "1234567890",
+		"test/a.spec.js":          "123",
+		".git/HEAD":               "ref",
+		"node_modules/a/index.js": "a",
+		defaultCompressedFileName: "cache",
+	})
+	assert.Nil(t, c.UploadWorkspace(context.TODO(), "org/build/task/workspace.tzst"))
+	// large.js does not fit in the limit after index.js, the excluded dirs and the cache are left out
+	assert.Equal(t, []string{"src/index.js", "test/a.spec.js"}, z.archived)
+	assert.Contains(t, azureClient.blobs, "org/build/task/workspace.tzst")
+}
+
+func contains(items []string, item string) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+	return false
+}
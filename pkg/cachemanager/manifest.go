@@ -0,0 +1,139 @@
+package cachemanager
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/LambdaTest/synapse/pkg/errs"
+	"github.com/LambdaTest/synapse/pkg/fileutils"
+	"github.com/LambdaTest/synapse/pkg/utils"
+)
+
+// manifestName is the file of the cache listing the checksums of the cached files
+const manifestName = ".tas-cache-manifest"
+
+// writeManifest writes the checksums of the regular files within the items to the manifest in the repo,
+// one "checksum  path" line per file
+func (c *cache) writeManifest(items []string) error {
+	files, err := c.walkItems(items)
+	if err != nil {
+		return err
+	}
+	paths := make([]string, 0, len(files))
+	for path, state := range files {
+		if state.mode.IsRegular() {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	sums, err := c.checksums(paths)
+	if err != nil {
+		return err
+	}
+	var b strings.Builder
+	for i, path := range paths {
+		fmt.Fprintf(&b, "%s  %s\n", sums[i], path)
+	}
+	return ioutil.WriteFile(filepath.Join(c.repoDir, manifestName), []byte(b.String()), 0644)
+}
+
+// hasManifest extracts only the manifest of the cache archive to tell whether the cache can be verified.
+// An archive the manifest cannot be extracted from, e.g. a corrupt one, is reported without manifest.
+func (c *cache) hasManifest(ctx context.Context, cachedFilePath string) (bool, error) {
+	dir, err := ioutil.TempDir(c.tempDir, "manifest-")
+	if err != nil {
+		return false, err
+	}
+	defer os.RemoveAll(dir)
+	if err := c.zstd.Decompress(ctx, cachedFilePath, true, dir, manifestName); err != nil {
+		if ctx.Err() != nil {
+			return false, err
+		}
+		c.logger.Debugf("failed to extract the checksum manifest of the cache, error: %v", err)
+	}
+	return fileutils.CheckIfExists(filepath.Join(dir, manifestName))
+}
+
+// checkManifest removes the manifest of the extracted cache, verifying the checksums of the files it
+// lists first if verification is enabled. A cache without manifest was written without verification,
+// it is only extracted when verification is disabled.
+func (c *cache) checkManifest() error {
+	manifestPath := filepath.Join(c.repoDir, manifestName)
+	f, err := os.Open(manifestPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer os.Remove(manifestPath)
+	defer f.Close()
+	if !c.verify {
+		return nil
+	}
+
+	var paths, want []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), "  ", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("%w: invalid manifest line %q", errs.ErrCacheCorrupt, scanner.Text())
+		}
+		want = append(want, parts[0])
+		paths = append(paths, parts[1])
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	sums, err := c.checksums(paths)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("%w: %v", errs.ErrCacheCorrupt, err)
+		}
+		return err
+	}
+	for i, path := range paths {
+		if sums[i] != want[i] {
+			c.logger.Errorf("Checksum of cached file %s is %s, expected %s", path, sums[i], want[i])
+			return fmt.Errorf("%w: %s", errs.ErrCacheCorrupt, path)
+		}
+	}
+	c.logger.Debugf("Verified the checksums of %d cached files", len(paths))
+	return nil
+}
+
+// checksums computes the checksums of the cached files in parallel, in the order of the paths
+func (c *cache) checksums(paths []string) ([]string, error) {
+	sums := make([]string, len(paths))
+	failures := make([]error, len(paths))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < runtime.NumCPU(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				sums[i], failures[i] = utils.ComputeChecksum(c.absPath(paths[i]))
+			}
+		}()
+	}
+	for i := range paths {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	for _, err := range failures {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return sums, nil
+}
@@ -0,0 +1,92 @@
+package cachemanager
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/LambdaTest/synapse/pkg/errs"
+	"github.com/LambdaTest/synapse/pkg/lumber"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheManifest(t *testing.T) {
+	logger, err := lumber.NewLogger(lumber.LoggingConfig{EnableConsole: true}, true, lumber.InstanceZapLogger)
+	if err != nil {
+		t.Fatalf("Could not instantiate logger %s", err.Error())
+	}
+	files := map[string]string{
+		"node_modules/a/index.js": "module.exports = 'a'",
+		"node_modules/b/index.js": "module.exports = 'b'",
+	}
+	tests := []struct {
+		name     string
+		manifest bool
+		tamper   bool
+		verify   bool
+		wantErr  error
+		wantHit  bool
+	}{
+		{"intact", true, false, true, nil, true},
+		{"tampered", true, true, true, errs.ErrCacheCorrupt, false},
+		{"tampered without verification", true, true, false, nil, true},
+		// the cache is ignored and replaced by a verified one on upload
+		{"without manifest", false, false, true, nil, false},
+		{"without manifest nor verification", false, false, false, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			azureClient := &memAzureClient{blobs: make(map[string][]byte)}
+			newCache := func() *cache {
+				return &cache{
+					azureClient: azureClient,
+					zstd:        &tarCompressor{},
+					logger:      logger,
+					tempDir:     t.TempDir(),
+					repoDir:     t.TempDir(),
+					timeout:     time.Minute,
+					verify:      tt.verify,
+				}
+			}
+			writer := newCache()
+			writer.verify = tt.manifest
+			writeFiles(t, writer.repoDir, files)
+			if err := writer.Upload(context.TODO(), "org/repo/v1", "node_modules"); err != nil {
+				t.Fatalf("Upload() error = %v", err)
+			}
+			// the manifest is not left in the repo
+			assert.NoFileExists(t, filepath.Join(writer.repoDir, manifestName))
+			if tt.tamper {
+				for url, blob := range azureClient.blobs {
+					azureClient.blobs[url] = bytes.Replace(blob, []byte("'b'"), []byte("'x'"), 1)
+				}
+			}
+
+			reader := newCache()
+			err := reader.Download(context.TODO(), "org/repo/v1")
+			assert.ErrorIs(t, err, tt.wantErr)
+			if tt.wantErr == nil {
+				assert.Nil(t, err)
+				assert.NoFileExists(t, filepath.Join(reader.repoDir, manifestName))
+				if tt.wantHit {
+					assert.FileExists(t, filepath.Join(reader.repoDir, "node_modules/a/index.js"))
+				} else {
+					assert.NoFileExists(t, filepath.Join(reader.repoDir, "node_modules/a/index.js"))
+				}
+				assert.Equal(t, tt.wantHit, reader.skipUpload)
+			}
+			if tt.wantErr == nil && !tt.wantHit {
+				// the post-run upload replaces the cache by a verified one
+				writeFiles(t, reader.repoDir, files)
+				if err := reader.Upload(context.TODO(), "org/repo/v1", "node_modules"); err != nil {
+					t.Fatalf("Upload() error = %v", err)
+				}
+				next := newCache()
+				assert.Nil(t, next.Download(context.TODO(), "org/repo/v1"))
+				assert.FileExists(t, filepath.Join(next.repoDir, "node_modules/a/index.js"))
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package cachemanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/LambdaTest/synapse/pkg/errs"
+)
+
+// maxDownloadAttempts is the number of times a cache download is attempted before giving up
+const maxDownloadAttempts = 3
+
+const (
+	partialSuffix    = ".part"
+	checkpointSuffix = ".checkpoint"
+)
+
+// downloadFile downloads the cache at the SAS URL to path. The bytes are written to a partial file
+// next to a checkpoint recording the cache key and the etag of the blob, a later download of the
+// same blob resumes from the end of the partial file. The download starts over if the blob changed
+// in between.
+func (c *cache) downloadFile(ctx context.Context, sasURL, cacheKey, path string) error {
+	partPath := path + partialSuffix
+	checkpointPath := path + checkpointSuffix
+
+	var offset int64
+	var etag string
+	if key, savedEtag, err := readCheckpoint(checkpointPath); err == nil && key == cacheKey {
+		if info, err := os.Stat(partPath); err == nil {
+			offset, etag = info.Size(), savedEtag
+		}
+	}
+	if offset > 0 {
+		c.logger.Infof("Resuming download of cache for key: %s from byte %d", cacheKey, offset)
+	}
+	body, blobEtag, err := c.azureClient.FindRangeUsingSASUrl(ctx, sasURL, offset, etag)
+	if errors.Is(err, errs.ErrBlobChanged) {
+		c.logger.Infof("Cache for key: %s changed since the download was interrupted, starting over", cacheKey)
+		offset = 0
+		body, blobEtag, err = c.azureClient.FindRangeUsingSASUrl(ctx, sasURL, 0, "")
+	}
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if offset > 0 {
+		flags = os.O_WRONLY | os.O_APPEND
+	} else if err := writeCheckpoint(checkpointPath, cacheKey, blobEtag); err != nil {
+		return err
+	}
+	out, err := os.OpenFile(partPath, flags, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, body); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(partPath, path); err != nil {
+		return err
+	}
+	return os.Remove(checkpointPath)
+}
+
+func readCheckpoint(path string) (cacheKey, etag string, err error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", "", err
+	}
+	parts := strings.SplitN(string(content), "\n", 2)
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("invalid checkpoint %s", path)
+	}
+	return parts[0], parts[1], nil
+}
+
+func writeCheckpoint(path, cacheKey, etag string) error {
+	return ioutil.WriteFile(path, []byte(cacheKey+"\n"+etag), 0644)
+}
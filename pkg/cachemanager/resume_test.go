@@ -0,0 +1,109 @@
+package cachemanager
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/LambdaTest/synapse/pkg/core"
+	"github.com/LambdaTest/synapse/pkg/errs"
+	"github.com/LambdaTest/synapse/pkg/lumber"
+	"github.com/stretchr/testify/assert"
+)
+
+// interruptingAzureClient serves a single blob, the first interruptions downloads fail after chunk bytes
+type interruptingAzureClient struct {
+	slowAzureClient
+	blob          []byte
+	etag          string
+	chunk         int
+	interruptions int
+	// offsets records the offset of every download
+	offsets []int64
+}
+
+type failingReader struct {
+	r io.Reader
+}
+
+func (f *failingReader) Read(p []byte) (int, error) {
+	n, err := f.r.Read(p)
+	if err == io.EOF {
+		return n, errors.New("connection reset by peer")
+	}
+	return n, err
+}
+
+func (f *interruptingAzureClient) FindRangeUsingSASUrl(ctx context.Context, sasURL string, offset int64, etag string) (io.ReadCloser, string, error) {
+	f.offsets = append(f.offsets, offset)
+	if etag != "" && etag != f.etag {
+		return nil, "", errs.ErrBlobChanged
+	}
+	var r io.Reader = bytes.NewReader(f.blob[offset:])
+	if f.interruptions > 0 {
+		f.interruptions--
+		r = &failingReader{r: io.LimitReader(r, int64(f.chunk))}
+	}
+	return ioutil.NopCloser(r), f.etag, nil
+}
+
+// recordingCompressor records the content of the decompressed archive
+type recordingCompressor struct {
+	core.ZstdCompressor
+	content []byte
+}
+
+func (r *recordingCompressor) Decompress(ctx context.Context, filePath string, preservePath bool, workingDirectory string, filesToDecompress ...string) error {
+	var err error
+	r.content, err = ioutil.ReadFile(filePath)
+	return err
+}
+
+func TestDownloadResume(t *testing.T) {
+	logger, err := lumber.NewLogger(lumber.LoggingConfig{EnableConsole: true}, true, lumber.InstanceZapLogger)
+	if err != nil {
+		t.Fatalf("Could not instantiate logger %s", err.Error())
+	}
+	blob := bytes.Repeat([]byte("0123456789"), 100)
+
+	t.Run("resumes within the download", func(t *testing.T) {
+		azureClient := &interruptingAzureClient{blob: blob, etag: "v1", chunk: 400, interruptions: 2}
+		z := &recordingCompressor{}
+		c := &cache{azureClient: azureClient, zstd: z, logger: logger, tempDir: t.TempDir(), timeout: time.Minute}
+		assert.Nil(t, c.Download(context.Background(), "org/repo/key"))
+		assert.Equal(t, []int64{0, 400, 800}, azureClient.offsets)
+		assert.Equal(t, blob, z.content)
+	})
+
+	t.Run("resumes a later download", func(t *testing.T) {
+		azureClient := &interruptingAzureClient{blob: blob, etag: "v1", chunk: 100, interruptions: maxDownloadAttempts}
+		z := &recordingCompressor{}
+		tempDir := t.TempDir()
+		c := &cache{azureClient: azureClient, zstd: z, logger: logger, tempDir: tempDir, timeout: time.Minute}
+		assert.NotNil(t, c.Download(context.Background(), "org/repo/key"))
+		assert.Nil(t, z.content)
+
+		retry := &cache{azureClient: azureClient, zstd: z, logger: logger, tempDir: tempDir, timeout: time.Minute}
+		assert.Nil(t, retry.Download(context.Background(), "org/repo/key"))
+		assert.Equal(t, []int64{0, 100, 200, 300}, azureClient.offsets)
+		assert.Equal(t, blob, z.content)
+	})
+
+	t.Run("starts over when the blob changed", func(t *testing.T) {
+		azureClient := &interruptingAzureClient{blob: blob, etag: "v1", chunk: 100, interruptions: maxDownloadAttempts}
+		z := &recordingCompressor{}
+		tempDir := t.TempDir()
+		c := &cache{azureClient: azureClient, zstd: z, logger: logger, tempDir: tempDir, timeout: time.Minute}
+		assert.NotNil(t, c.Download(context.Background(), "org/repo/key"))
+
+		azureClient.etag = "v2"
+		retry := &cache{azureClient: azureClient, zstd: z, logger: logger, tempDir: tempDir, timeout: time.Minute}
+		assert.Nil(t, retry.Download(context.Background(), "org/repo/key"))
+		assert.Equal(t, []int64{0, 100, 200, 300, 0}, azureClient.offsets)
+		assert.Equal(t, blob, z.content)
+	})
+}
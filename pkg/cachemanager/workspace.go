@@ -0,0 +1,89 @@
+package cachemanager
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/LambdaTest/synapse/pkg/global"
+)
+
+// workspaceExcludes are the directories left out of the workspace snapshot, they are large
+// and restored by cloning the repo and downloading the cache
+var workspaceExcludes = map[string]bool{".git": true, nodeModules: true}
+
+// UploadWorkspace uploads a snapshot of the repo directory at blobPath for inspecting a failed task.
+// The files beyond the size limit are left out of the snapshot.
+func (c *cache) UploadWorkspace(ctx context.Context, blobPath string) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	return c.wrapTimeoutErr(ctx, c.uploadWorkspace(ctx, blobPath))
+}
+
+func (c *cache) uploadWorkspace(ctx context.Context, blobPath string) error {
+	files, skipped, err := c.workspaceFiles()
+	if err != nil {
+		c.logger.Errorf("failed to list the workspace files, error: %v", err)
+		return err
+	}
+	if skipped > 0 {
+		c.logger.Warnf("workspace snapshot exceeds %d bytes, leaving out %d files", c.workspaceLimit, skipped)
+	}
+	if len(files) == 0 {
+		c.logger.Infof("No files found in the workspace, skipping snapshot")
+		return nil
+	}
+
+	snapshotPath := filepath.Join(c.tempDir, global.WorkspaceSnapshotFile)
+	defer os.Remove(snapshotPath)
+	if err := c.zstd.Compress(ctx, snapshotPath, false, c.repoDir, files...); err != nil {
+		c.logger.Errorf("error while compressing the workspace, error: %v", err)
+		return err
+	}
+	f, err := os.Open(snapshotPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := c.azureClient.Create(ctx, blobPath, f, "application/zstd"); err != nil {
+		c.logger.Errorf("error while uploading workspace snapshot %s, error: %v", blobPath, err)
+		return err
+	}
+	return nil
+}
+
+// workspaceFiles returns the paths of the files of the snapshot, relative to the repo directory,
+// along with the number of files left out by the size limit
+func (c *cache) workspaceFiles() (files []string, skipped int, err error) {
+	var size int64
+	err = filepath.Walk(c.repoDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if path == c.repoDir && os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() {
+			if workspaceExcludes[info.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		// the cache archive is not part of the workspace
+		if path == filepath.Join(c.repoDir, defaultCompressedFileName) {
+			return nil
+		}
+		if c.workspaceLimit > 0 && size+info.Size() > c.workspaceLimit {
+			skipped++
+			return nil
+		}
+		size += info.Size()
+		rel, err := filepath.Rel(c.repoDir, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, rel)
+		return nil
+	})
+	return files, skipped, err
+}
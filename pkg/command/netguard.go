@@ -0,0 +1,82 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/LambdaTest/synapse/pkg/core"
+	"github.com/LambdaTest/synapse/pkg/lumber"
+)
+
+// proxyEnvVars are the environment variables pointing the network clients at the guard
+var proxyEnvVars = []string{"HTTP_PROXY", "HTTPS_PROXY", "ALL_PROXY", "http_proxy", "https_proxy", "all_proxy",
+	"npm_config_proxy", "npm_config_https_proxy"}
+
+// netGuard is a proxy on the loopback interface refusing every request
+type netGuard struct {
+	logger   lumber.Logger
+	listener net.Listener
+	server   *http.Server
+	mu       sync.Mutex
+	attempts []core.NetworkAttempt
+}
+
+// BlockNetwork starts a guard refusing the outbound connections of the test processes
+func (m *manager) BlockNetwork() (core.NetworkGuard, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	g := &netGuard{logger: m.logger, listener: listener}
+	g.server = &http.Server{Handler: g, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := g.server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			g.logger.Errorf("network guard stopped, error: %v", err)
+		}
+	}()
+	m.logger.Debugf("blocking the network of the tests through %s", listener.Addr())
+	return g, nil
+}
+
+// ServeHTTP refuses the proxied request or tunnel and records it
+func (g *netGuard) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// the host of a tunnel is in the request line, the one of a proxied request in its URL
+	host := r.Host
+	if r.Method != http.MethodConnect && r.URL.Host != "" {
+		host = r.URL.Host
+	}
+	g.mu.Lock()
+	g.attempts = append(g.attempts, core.NetworkAttempt{Host: host, Time: time.Now()})
+	g.mu.Unlock()
+	g.logger.Warnf("refused network access of the tests to %s", host)
+	http.Error(w, fmt.Sprintf("network access to %s is blocked during the tests", host), http.StatusForbidden)
+}
+
+// Env returns the environment variables routing the connections of a process through the guard
+func (g *netGuard) Env() []string {
+	proxyURL := "http://" + g.listener.Addr().String()
+	env := make([]string, 0, len(proxyEnvVars)+2)
+	for _, name := range proxyEnvVars {
+		env = append(env, name+"="+proxyURL)
+	}
+	// the servers started by the tests stay reachable
+	return append(env, "NO_PROXY=localhost,127.0.0.1,::1", "no_proxy=localhost,127.0.0.1,::1")
+}
+
+// Attempts returns the connections refused so far
+func (g *netGuard) Attempts() []core.NetworkAttempt {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return append([]core.NetworkAttempt(nil), g.attempts...)
+}
+
+// Close stops the guard
+func (g *netGuard) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	return g.server.Shutdown(ctx)
+}
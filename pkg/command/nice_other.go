@@ -0,0 +1,11 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd
+
+package command
+
+import "os/exec"
+
+// niceCommand is a no-op on the platforms without process niceness
+func niceCommand(cmd *exec.Cmd, nice int) error {
+	return nil
+}
@@ -0,0 +1,22 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+// +build linux darwin freebsd netbsd openbsd
+
+package command
+
+import (
+	"os/exec"
+	"strconv"
+)
+
+// niceCommand makes the command run through nice, so that all of its threads and the processes it
+// spawns start with the given niceness
+func niceCommand(cmd *exec.Cmd, nice int) error {
+	path, err := exec.LookPath("nice")
+	if err != nil {
+		return err
+	}
+	args := append([]string{"nice", "-n", strconv.Itoa(nice), cmd.Path}, cmd.Args[1:]...)
+	cmd.Path = path
+	cmd.Args = args
+	return nil
+}
@@ -0,0 +1,59 @@
+//go:build linux
+// +build linux
+
+package command
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/LambdaTest/synapse/pkg/lumber"
+	"github.com/stretchr/testify/assert"
+)
+
+// niceness returns the nice level of the process from its stat file
+func niceness(t *testing.T, pid int) int {
+	stat, err := ioutil.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "stat"))
+	if err != nil {
+		t.Fatalf("failed to read the stat of process %d, error: %v", pid, err)
+	}
+	// the fields after the command name, which is in parentheses, start with the state
+	fields := strings.Fields(string(stat[strings.LastIndexByte(string(stat), ')')+1:]))
+	nice, err := strconv.Atoi(fields[16])
+	if err != nil {
+		t.Fatalf("failed to parse the nice level of process %d, error: %v", pid, err)
+	}
+	return nice
+}
+
+func TestDeprioritize(t *testing.T) {
+	logger, err := lumber.NewLogger(lumber.LoggingConfig{EnableConsole: true}, true, lumber.InstanceZapLogger)
+	if err != nil {
+		t.Fatalf("Could not instantiate logger %s", err.Error())
+	}
+	tests := []struct {
+		name      string
+		niceLevel int
+		want      func(base int) int
+	}{
+		{"default level leaves the command unchanged", 0, func(base int) int { return base }},
+		{"command starts with the nice level", 10, func(base int) int { return 10 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// nice without arguments prints the niceness it runs with
+			cmd := exec.Command("nice")
+			assert.Nil(t, (&manager{logger: logger, niceLevel: tt.niceLevel}).Deprioritize(cmd))
+			out, err := cmd.Output()
+			if err != nil {
+				t.Fatalf("failed to run command, error: %v", err)
+			}
+			assert.Equal(t, strconv.Itoa(tt.want(niceness(t, os.Getpid()))), strings.TrimSpace(string(out)))
+		})
+	}
+}
@@ -0,0 +1,30 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd
+
+package command
+
+import (
+	"context"
+	"os/exec"
+	"time"
+)
+
+// startProcessGroup starts the command and returns a function waiting for it. The platforms without
+// process groups only kill the command itself on context cancellation, its child processes survive it.
+func startProcessGroup(ctx context.Context, cmd *exec.Cmd, gracePeriod time.Duration) (wait func() error, err error) {
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-done:
+		case <-ctx.Done():
+			_ = cmd.Process.Kill()
+		}
+	}()
+	return func() error {
+		defer close(done)
+		return cmd.Wait()
+	}, nil
+}
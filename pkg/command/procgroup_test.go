@@ -0,0 +1,90 @@
+//go:build linux
+// +build linux
+
+package command
+
+import (
+	"context"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// isAlive reports whether the process is running, zombies are considered dead
+func isAlive(pid int) bool {
+	stat, err := ioutil.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return false
+	}
+	fields := strings.Fields(string(stat))
+	return len(fields) > 2 && fields[2] != "Z"
+}
+
+func TestStartProcessGroupCancel(t *testing.T) {
+	tests := []struct {
+		name  string
+		start func(ctx context.Context, cmd *exec.Cmd) (func() error, error)
+	}{
+		{"user and internal commands", func(ctx context.Context, cmd *exec.Cmd) (func() error, error) {
+			return startProcessGroup(ctx, cmd, 100*time.Millisecond)
+		}},
+		// the discovery and execution runners are started through the execution manager
+		{"runners", (&manager{killGracePeriod: 100 * time.Millisecond}).StartProcessGroup},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testCancelKillsChildren(t, tt.start)
+		})
+	}
+}
+
+// testCancelKillsChildren checks that canceling a command started by start kills the processes it spawned
+func testCancelKillsChildren(t *testing.T, start func(ctx context.Context, cmd *exec.Cmd) (func() error, error)) {
+	pidFile := filepath.Join(t.TempDir(), "child.pid")
+	// the child ignores SIGTERM, so it is only reaped by the SIGKILL after the grace period
+	cmd := exec.Command("/bin/bash", "-c", "trap '' TERM; (trap '' TERM; sleep 100) & echo $! > "+pidFile+"; wait")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	wait, err := start(ctx, cmd)
+	if err != nil {
+		t.Fatalf("start() error = %v", err)
+	}
+
+	var childPid int
+	for i := 0; i < 100 && childPid == 0; i++ {
+		if raw, err := ioutil.ReadFile(pidFile); err == nil {
+			childPid, _ = strconv.Atoi(strings.TrimSpace(string(raw)))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if childPid == 0 {
+		t.Fatal("child process did not start")
+	}
+
+	cancel()
+	if err := wait(); err == nil {
+		t.Error("expected the canceled command to fail")
+	}
+	for i := 0; i < 100 && isAlive(childPid); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if isAlive(childPid) {
+		_ = syscall.Kill(childPid, syscall.SIGKILL)
+		t.Errorf("child process %d outlived the canceled command", childPid)
+	}
+}
+
+func TestStartProcessGroupSuccess(t *testing.T) {
+	wait, err := startProcessGroup(context.Background(), exec.Command("/bin/bash", "-c", "exit 0"), time.Second)
+	if err != nil {
+		t.Fatalf("startProcessGroup() error = %v", err)
+	}
+	if err := wait(); err != nil {
+		t.Errorf("wait() error = %v", err)
+	}
+}
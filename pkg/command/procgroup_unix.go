@@ -0,0 +1,41 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+// +build linux darwin freebsd netbsd openbsd
+
+package command
+
+import (
+	"context"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// startProcessGroup starts the command in its own process group and returns a function waiting for it.
+// On context cancellation the whole group is sent SIGTERM, followed by SIGKILL once the grace period
+// is over, so that the child processes spawned by the command do not outlive it.
+func startProcessGroup(ctx context.Context, cmd *exec.Cmd, gracePeriod time.Duration) (wait func() error, err error) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	pgid := cmd.Process.Pid
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-done:
+			return
+		case <-ctx.Done():
+		}
+		_ = syscall.Kill(-pgid, syscall.SIGTERM)
+		select {
+		case <-done:
+		case <-time.After(gracePeriod):
+		}
+		// children can ignore SIGTERM or outlive the process group leader
+		_ = syscall.Kill(-pgid, syscall.SIGKILL)
+	}()
+	return func() error {
+		defer close(done)
+		return cmd.Wait()
+	}, nil
+}
@@ -7,7 +7,9 @@ import (
 	"os"
 	"os/exec"
 	"strings"
+	"time"
 
+	"github.com/LambdaTest/synapse/config"
 	"github.com/LambdaTest/synapse/pkg/core"
 	"github.com/LambdaTest/synapse/pkg/global"
 	"github.com/LambdaTest/synapse/pkg/logstream"
@@ -15,18 +17,28 @@ import (
 )
 
 type manager struct {
-	logger       lumber.Logger
-	secretParser core.SecretParser
-	azureClient  core.AzureClient
+	logger lumber.Logger
+	// killGracePeriod is the time given to the commands to exit after SIGTERM on cancellation
+	killGracePeriod time.Duration
+	secretParser    core.SecretParser
+	azureClient     core.AzureClient
+	// logLimit is the maximum number of bytes of the output logged per user command
+	logLimit int
+	// niceLevel is the niceness of the discovery and execution commands, 0 leaves it unchanged
+	niceLevel int
 }
 
 // NewExecutionManager returns new instance of manger
 func NewExecutionManager(secretParser core.SecretParser,
 	azureClient core.AzureClient,
+	cfg *config.NucleusConfig,
 	logger lumber.Logger) core.ExecutionManager {
 	return &manager{logger: logger,
-		secretParser: secretParser,
-		azureClient:  azureClient}
+		secretParser:    secretParser,
+		azureClient:     azureClient,
+		killGracePeriod: time.Duration(cfg.KillGracePeriod) * time.Second,
+		logLimit:        cfg.CommandLogLimit * global.MB,
+		niceLevel:       cfg.NiceLevel}
 }
 
 // ExecuteUserCommands executes user commands
@@ -53,23 +65,27 @@ func (m *manager) ExecuteUserCommands(ctx context.Context,
 	logWriter := lumber.NewWriter(m.logger)
 	defer logWriter.Close()
 	multiWriter := io.MultiWriter(logWriter, azureWriter)
-	maskWriter := logstream.NewMasker(multiWriter, secretData)
+	limitWriter := logstream.NewLimiter(multiWriter, m.logLimit)
+	defer limitWriter.Close()
+	maskWriter := logstream.NewMasker(limitWriter, secretData)
 
-	cmd := exec.CommandContext(ctx, "/bin/bash", "-c", script)
+	cmd := exec.Command("/bin/bash", "-c", script)
 	cmd.Dir = global.RepoDir
 	cmd.Env = envVars
 	cmd.Stdout = maskWriter
 	cmd.Stderr = maskWriter
 
-	if startErr := cmd.Start(); startErr != nil {
+	wait, startErr := startProcessGroup(ctx, cmd, m.killGracePeriod)
+	if startErr != nil {
 		m.logger.Errorf("failed to start command: %s, error: %v", commandType, startErr)
 		return startErr
 	}
 	m.logger.Debugf("command of type %s started with id %d", commandType, cmd.Process.Pid)
-	if execErr := cmd.Wait(); execErr != nil {
+	if execErr := wait(); execErr != nil {
 		m.logger.Errorf("command %s, exited with error: %v", commandType, execErr)
 		return execErr
 	}
+	limitWriter.Close()
 	azureWriter.Close()
 	if uploadErr := <-errChan; uploadErr != nil {
 		m.logger.Errorf("failed to upload logs for command %s, error: %v", commandType, uploadErr)
@@ -85,7 +101,7 @@ func (m *manager) ExecuteInternalCommands(ctx context.Context,
 	cwd string,
 	envMap, secretData map[string]string) error {
 	argsString := strings.Join(commands, " ")
-	cmd := exec.CommandContext(ctx, "/bin/bash", "-c", argsString)
+	cmd := exec.Command("/bin/bash", "-c", argsString)
 	if cwd != "" {
 		cmd.Dir = cwd
 	}
@@ -94,7 +110,11 @@ func (m *manager) ExecuteInternalCommands(ctx context.Context,
 	cmd.Stderr = logWriter
 	cmd.Stdout = logWriter
 	m.logger.Debugf("Executing command: %s, of type %s", cmd.String(), commandType)
-	if err := cmd.Run(); err != nil {
+	wait, err := startProcessGroup(ctx, cmd, m.killGracePeriod)
+	if err == nil {
+		err = wait()
+	}
+	if err != nil {
 		m.logger.Errorf("command %s of type %s failed with error: %v", cmd.String(), commandType, err)
 		return err
 	}
@@ -114,6 +134,23 @@ func (m *manager) GetEnvVariables(envMap, secretData map[string]string) ([]strin
 	return envVars, nil
 }
 
+// Deprioritize makes the command, not started yet, run with the configured nice level
+func (m *manager) Deprioritize(cmd *exec.Cmd) error {
+	if m.niceLevel == 0 {
+		return nil
+	}
+	if err := niceCommand(cmd, m.niceLevel); err != nil {
+		return err
+	}
+	m.logger.Debugf("running command %s with nice level %d", cmd.Path, m.niceLevel)
+	return nil
+}
+
+// StartProcessGroup starts the command in its own process group, the group is terminated on context cancellation
+func (m *manager) StartProcessGroup(ctx context.Context, cmd *exec.Cmd) (wait func() error, err error) {
+	return startProcessGroup(ctx, cmd, m.killGracePeriod)
+}
+
 // StoreCommandLogs stores the command logs to blob
 func (m *manager) StoreCommandLogs(ctx context.Context, blobPath string, reader io.Reader) <-chan error {
 	errChan := make(chan error, 1)
@@ -0,0 +1,52 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/LambdaTest/synapse/pkg/errs"
+)
+
+// resolveBranch runs the branch hook and returns the branch it prints. The branch of the payload
+// is passed to the hook as BRANCH_NAME.
+func resolveBranch(ctx context.Context, hook, branch string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "/bin/bash", "-c", hook)
+	cmd.Env = append(os.Environ(), "BRANCH_NAME="+branch)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("branch hook failed: %w, stderr: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	resolved := strings.TrimSpace(stdout.String())
+	if !validRefName(resolved) {
+		return "", fmt.Errorf("%w %q printed by the branch hook", errs.ErrInvalidBranchName, resolved)
+	}
+	return resolved, nil
+}
+
+// validRefName reports whether name is a legal branch name, following the rules of git check-ref-format
+func validRefName(name string) bool {
+	if name == "" || name == "@" || strings.HasPrefix(name, "-") ||
+		strings.HasPrefix(name, "/") || strings.HasSuffix(name, "/") || strings.HasSuffix(name, ".") {
+		return false
+	}
+	if strings.Contains(name, "..") || strings.Contains(name, "@{") || strings.Contains(name, "//") {
+		return false
+	}
+	for _, r := range name {
+		if r < 0x20 || r == 0x7f || strings.ContainsRune(" ~^:?*[\\", r) {
+			return false
+		}
+	}
+	for _, component := range strings.Split(name, "/") {
+		if strings.HasPrefix(component, ".") || strings.HasSuffix(component, ".lock") {
+			return false
+		}
+	}
+	return true
+}
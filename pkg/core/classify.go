@@ -0,0 +1,50 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/LambdaTest/synapse/pkg/errs"
+)
+
+// failureClass sets the status of a task whose failed command matches the pattern
+type failureClass struct {
+	status  Status
+	pattern *regexp.Regexp
+}
+
+// parseFailureClasses parses the failure classes given as status=regex pairs,
+// the status is either error, which is retried, or failed
+func parseFailureClasses(entries []string) ([]failureClass, error) {
+	classes := make([]failureClass, 0, len(entries))
+	for _, entry := range entries {
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 || (Status(parts[0]) != Error && Status(parts[0]) != Failed) {
+			return nil, fmt.Errorf("invalid failure class %q, expected error=regex or failed=regex", entry)
+		}
+		pattern, err := regexp.Compile(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern of failure class %q: %w", entry, err)
+		}
+		classes = append(classes, failureClass{status: Status(parts[0]), pattern: pattern})
+	}
+	return classes, nil
+}
+
+// classifyFailure returns the status of the first class matching the error and the output of the
+// failed command, ok is false if there is no match
+func classifyFailure(err error, classes []failureClass) (status Status, pattern string, ok bool) {
+	text := err.Error()
+	var cmdErr *errs.CommandError
+	if errors.As(err, &cmdErr) {
+		text += "\n" + cmdErr.Output
+	}
+	for _, class := range classes {
+		if class.pattern.MatchString(text) {
+			return class.status, class.pattern.String(), true
+		}
+	}
+	return "", "", false
+}
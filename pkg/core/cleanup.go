@@ -0,0 +1,45 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/LambdaTest/synapse/pkg/global"
+)
+
+// tempArtifacts are the patterns of the files which the services leave in the temp dir
+var tempArtifacts = []string{
+	// downloaded cache archive along with its partial download and checkpoint
+	"cache.tzst*",
+	global.WorkspaceSnapshotFile,
+	// test locators passed to the runner
+	"locators",
+	// diff passed to the runner during discovery
+	"tas-diff-*.txt",
+	// file lists passed to zstd
+	"*-manifest.txt",
+}
+
+// cleanupTempDir removes the artifacts of the run from the temp dir, so that they
+// do not accumulate on persistent runners. Failures are only logged.
+func (pl *Pipeline) cleanupTempDir(payload *Payload) {
+	patterns := tempArtifacts
+	if payload.TargetCommit != "" {
+		// repo archive downloaded by the clone
+		patterns = append(patterns, payload.TargetCommit+".*")
+	}
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(filepath.Join(pl.Cfg.TempDir, pattern))
+		if err != nil {
+			pl.Logger.Errorf("invalid cleanup pattern %s, error: %v", pattern, err)
+			continue
+		}
+		for _, path := range matches {
+			if err := os.RemoveAll(path); err != nil {
+				pl.Logger.Errorf("failed to remove %s, error: %v", path, err)
+				continue
+			}
+			pl.Logger.Debugf("Removed %s", path)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package core
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+
+	"github.com/LambdaTest/synapse/pkg/fileutils"
+	"github.com/LambdaTest/synapse/pkg/logstream"
+	"github.com/LambdaTest/synapse/pkg/utils"
+)
+
+// secretEnvName matches the names of the env file variables whose values are masked in the logs
+var secretEnvName = regexp.MustCompile(`(?i)token|secret|passw(or)?d|credential|auth|api_?key|private_?key`)
+
+// loadEnvFile merges the variables of the env file of the tas configuration into the env of every step,
+// the env of the step takes precedence. The values of secret looking variables are masked in the logs.
+func loadEnvFile(repoDir string, tasConfig *TASConfig) error {
+	path := filepath.Join(repoDir, tasConfig.EnvFile)
+	if !fileutils.IsWithin(repoDir, path) {
+		return fmt.Errorf("env file %s is outside of the repo", tasConfig.EnvFile)
+	}
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	fileEnv, err := utils.ParseEnvFile(string(content))
+	if err != nil {
+		return fmt.Errorf("env file %s: %w", tasConfig.EnvFile, err)
+	}
+	var masked []string
+	for k, v := range fileEnv {
+		if len(v) > 1 && secretEnvName.MatchString(k) {
+			masked = append(masked, regexp.QuoteMeta(v))
+		}
+	}
+	if err := logstream.RegisterPatterns(masked); err != nil {
+		return err
+	}
+	for _, envMap := range []*map[string]string{stepEnv(tasConfig.Premerge), stepEnv(tasConfig.Postmerge),
+		runEnv(tasConfig.Prerun), runEnv(tasConfig.Postrun)} {
+		if envMap == nil {
+			continue
+		}
+		merged := make(map[string]string, len(fileEnv)+len(*envMap))
+		for k, v := range fileEnv {
+			merged[k] = v
+		}
+		for k, v := range *envMap {
+			merged[k] = v
+		}
+		*envMap = merged
+	}
+	return nil
+}
+
+func stepEnv(m *Merge) *map[string]string {
+	if m == nil {
+		return nil
+	}
+	return &m.EnvMap
+}
+
+func runEnv(r *Run) *map[string]string {
+	if r == nil {
+		return nil
+	}
+	return &r.EnvMap
+}
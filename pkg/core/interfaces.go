@@ -3,6 +3,7 @@ package core
 import (
 	"context"
 	"io"
+	"os/exec"
 )
 
 // PayloadManager defines operations for payload
@@ -34,19 +35,21 @@ type DiffManager interface {
 
 // TestDiscoveryService services discovery of tests
 type TestDiscoveryService interface {
-	// Discover executes the test discovery scripts.
-	Discover(ctx context.Context, tasConfig *TASConfig, payload *Payload, secretData map[string]string, diff map[string]int) error
+	// Discover executes the test discovery scripts, the non-fatal conditions are added to warns.
+	Discover(ctx context.Context, tasConfig *TASConfig, payload *Payload, secretData map[string]string, diff map[string]int, warns *Warnings) error
 }
 
 // TestBlockListService is used for fetching blocklisted tests
 type TestBlockListService interface {
-	GetBlockListedTests(ctx context.Context, tasConfig *TASConfig, repo string) error
+	// GetBlockListedTests writes the blocklisted tests, the non-fatal conditions are added to warns.
+	GetBlockListedTests(ctx context.Context, tasConfig *TASConfig, repo string, warns *Warnings) error
 }
 
 // TestExecutionService services execution of tests
 type TestExecutionService interface {
-	// Run executes the test execution scripts.
-	Run(ctx context.Context, tasConfig *TASConfig, payload *Payload, coverageDirectory string, secretMap map[string]string) (*ExecutionResult, error)
+	// Run executes the test execution scripts, the non-fatal conditions are added to warns.
+	Run(ctx context.Context, tasConfig *TASConfig, payload *Payload, coverageDirectory string, secretMap map[string]string,
+		warns *Warnings) (*ExecutionResult, error)
 }
 
 // CoverageService services coverage of tests
@@ -81,6 +84,9 @@ type NotifMessage struct {
 // AzureClient defines operation for working with azure store
 type AzureClient interface {
 	FindUsingSASUrl(ctx context.Context, sasURL string) (io.ReadCloser, error)
+	// FindRangeUsingSASUrl downloads the object from offset, it fails with ErrBlobChanged if the etag,
+	// when given, does not match the object anymore. It returns the etag of the object.
+	FindRangeUsingSASUrl(ctx context.Context, sasURL string, offset int64, etag string) (io.ReadCloser, string, error)
 	Find(ctx context.Context, path string) (io.ReadCloser, error)
 	Create(ctx context.Context, path string, reader io.Reader, mimeType string) (string, error)
 	CreateUsingSASURL(ctx context.Context, sasURL string, reader io.Reader, mimeType string) (string, error)
@@ -91,15 +97,17 @@ type AzureClient interface {
 // ZstdCompressor performs zstd compression and decompression
 type ZstdCompressor interface {
 	Compress(ctx context.Context, compressedFileName string, preservePath bool, workingDirectory string, filesToCompress ...string) error
-	Decompress(ctx context.Context, filePath string, preservePath bool, workingDirectory string) error
+	Decompress(ctx context.Context, filePath string, preservePath bool, workingDirectory string, filesToDecompress ...string) error
 }
 
 // CacheStore defines operation for working with the cache
 type CacheStore interface {
-	// Download downloads cache present at cacheKey
-	Download(ctx context.Context, cacheKey string) error
+	// Download downloads cache present at cacheKey, itemsToCache are the paths which are uploaded later
+	Download(ctx context.Context, cacheKey string, itemsToCache ...string) error
 	// Upload creates, compresses and uploads cache at cacheKey
 	Upload(ctx context.Context, cacheKey string, itemsToCompress ...string) error
+	// UploadWorkspace uploads a compressed snapshot of the repo directory at blobPath
+	UploadWorkspace(ctx context.Context, blobPath string) error
 }
 
 // SecretParser defines operation for parsing the vault secrets in given path
@@ -107,6 +115,18 @@ type SecretParser interface {
 	GetOauthSecret(filepath string) (*Oauth, error)
 	GetRepoSecret(string) (map[string]string, error)
 	SubstituteSecret(command string, secretData map[string]string) (string, error)
+	// UnusedSecrets returns the names of the secrets which were never referenced
+	UnusedSecrets(secretData map[string]string) []string
+}
+
+// NetworkGuard refuses the outbound connections of the processes started with its environment and records them
+type NetworkGuard interface {
+	// Env returns the environment variables routing the connections of a process through the guard.
+	Env() []string
+	// Attempts returns the connections refused so far.
+	Attempts() []NetworkAttempt
+	// Close stops the guard.
+	Close() error
 }
 
 // ExecutionManager has responsibility for executing the preRun, postRun and internal commands
@@ -117,6 +137,13 @@ type ExecutionManager interface {
 	ExecuteInternalCommands(ctx context.Context, commandType CommandType, commands []string, cwd string, envMap, secretData map[string]string) error
 	// GetEnvVariables get the environment variables from the env map given by user.
 	GetEnvVariables(envMap, secretData map[string]string) ([]string, error)
+	// Deprioritize makes the discovery or execution command, before it is started, run with the configured nice level.
+	Deprioritize(cmd *exec.Cmd) error
+	// StartProcessGroup starts the discovery or execution command in its own process group and returns a function
+	// waiting for it. On context cancellation the whole group is terminated, so that the workers do not outlive it.
+	StartProcessGroup(ctx context.Context, cmd *exec.Cmd) (wait func() error, err error)
+	// BlockNetwork starts a guard refusing the outbound connections of the test processes.
+	BlockNetwork() (NetworkGuard, error)
 	// StoreCommandLogs stores the command logs in the azure.
 	StoreCommandLogs(ctx context.Context, blobPath string, reader io.Reader) <-chan error
 }
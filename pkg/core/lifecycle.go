@@ -1,39 +1,58 @@
 package core
 
 import (
-	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"regexp"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/LambdaTest/synapse/config"
 	"github.com/LambdaTest/synapse/pkg/errs"
 	"github.com/LambdaTest/synapse/pkg/fileutils"
 	"github.com/LambdaTest/synapse/pkg/global"
+	"github.com/LambdaTest/synapse/pkg/logstream"
 	"github.com/LambdaTest/synapse/pkg/lumber"
+	"github.com/LambdaTest/synapse/pkg/utils"
 )
 
 const (
 	endpointPostTestResults = "http://localhost:9876/results"
+	endpointLocalTestList   = "http://localhost:9876/test-list"
 )
 
 var endpointPostTestList string
 var endpointNeuronReport string
 
+// nvmDir is the directory where nvm installs the node versions
+var nvmDir = global.HomeDir + "/.nvm"
+
 // NewPipeline creates and returns a new Pipeline instance
 func NewPipeline(cfg *config.NucleusConfig, logger lumber.Logger) (*Pipeline, error) {
+	failureClasses, err := parseFailureClasses(cfg.FailureClasses)
+	if err != nil {
+		return nil, err
+	}
+	// the status policies are applied after the tests have run, so they are validated upfront
+	if err := validateStatusPolicies(cfg.EmptySuitePolicy, cfg.UnknownStatusPolicy); err != nil {
+		return nil, err
+	}
 	return &Pipeline{
 		Cfg:    cfg,
 		Logger: logger,
 		HttpClient: http.Client{
 			Timeout: 45 * time.Second,
 		},
+		failureClasses: failureClasses,
 	}, nil
 }
 
@@ -43,12 +62,17 @@ func (pl *Pipeline) Start(ctx context.Context) (err error) {
 	defer cancel()
 
 	var errRemark string
+	// errStatus is the status of the task when it fails
+	errStatus := Error
+	var secretMap map[string]string
 	startTime := time.Now()
+	warns := NewWarnings(pl.Logger)
 
 	pl.Logger.Debugf("Starting pipeline.....")
 	pl.Logger.Debugf("Fetching config")
 
-	endpointPostTestList = global.NeuronHost + "/test-list"
+	// the discovered tests are captured by the local api server, which sorts them before they are posted
+	endpointPostTestList = endpointLocalTestList
 	endpointNeuronReport = global.NeuronHost + "/report"
 	// fetch configuration
 	payload, err := pl.PayloadManager.FetchPayload(ctx, pl.Cfg.PayloadAddress)
@@ -61,13 +85,18 @@ func (pl *Pipeline) Start(ctx context.Context) (err error) {
 		pl.Logger.Fatalf("error while validating payload %v", err)
 	}
 
+	if global.CorrelationID == "" {
+		global.SetCorrelationID(payload.BuildID)
+	}
 	pl.Logger.Debugf("Payload for current task: %+v \n", *payload)
 
 	if pl.Cfg.CoverageMode {
+		// the coverage job has no task status, the error is surfaced to the caller
 		if err := pl.CoverageService.MergeAndUpload(ctx, payload); err != nil {
-			pl.Logger.Fatalf("error while merge and upload coverage files %v", err)
+			pl.Logger.Errorf("error while merge and upload coverage files %v", err)
+			return err
 		}
-		os.Exit(0)
+		return nil
 	}
 
 	oauth, err := pl.SecretParser.GetOauthSecret(global.OauthSecretPath)
@@ -89,16 +118,17 @@ func (pl *Pipeline) Start(ctx context.Context) (err error) {
 	}
 
 	taskPayload := &TaskPayload{
-		TaskID:      payload.TaskID,
-		BuildID:     payload.BuildID,
-		RepoSlug:    payload.RepoSlug,
-		RepoLink:    payload.RepoLink,
-		OrgID:       payload.OrgID,
-		RepoID:      payload.RepoID,
-		CommitID:    payload.TargetCommit,
-		GitProvider: payload.GitProvider,
-		StartTime:   startTime,
-		Status:      Running,
+		TaskID:       payload.TaskID,
+		BuildID:      payload.BuildID,
+		RepoSlug:     payload.RepoSlug,
+		RepoLink:     payload.RepoLink,
+		OrgID:        payload.OrgID,
+		RepoID:       payload.RepoID,
+		CommitID:     payload.TargetCommit,
+		GitProvider:  payload.GitProvider,
+		StartTime:    startTime,
+		Status:       Running,
+		FeatureFlags: payload.FeatureFlags,
 	}
 	if pl.Cfg.DiscoverMode {
 		taskPayload.Type = DiscoveryTask
@@ -111,6 +141,11 @@ func (pl *Pipeline) Start(ctx context.Context) (err error) {
 		pl.Logger.Fatalf("failed to update task status %v", err)
 	}
 
+	if pl.Cfg.CleanupAfterRun {
+		// deferred first so that it runs after the workspace snapshot and the final status
+		defer pl.cleanupTempDir(payload)
+	}
+
 	// update task status when pipeline exits
 	defer func() {
 		taskPayload.EndTime = time.Now()
@@ -123,21 +158,49 @@ func (pl *Pipeline) Start(ctx context.Context) (err error) {
 				taskPayload.Status = Aborted
 				taskPayload.Remark = "Task aborted"
 			} else {
-				taskPayload.Status = Error
+				taskPayload.Status = errStatus
 				taskPayload.Remark = errRemark
 			}
 		}
-		if err := pl.Task.UpdateStatus(taskPayload); err != nil {
-			pl.Logger.Fatalf("failed to update task status %v", err)
+		maskTaskPayload(taskPayload, warns.List(), secretMap)
+		if pl.Cfg.PreserveWorkspaceOnFailure && (taskPayload.Status == Error || taskPayload.Status == Failed) {
+			pl.preserveWorkspace(ctx, payload)
 		}
+		pl.updateFinalStatus(taskPayload)
 	}()
 
+	if token := skipTestsToken(payload, pl.Cfg.SkipTestsTokens); token != "" {
+		pl.Logger.Infof("Skipping tests, commit message contains %s", token)
+		taskPayload.Status = Skipped
+		taskPayload.Remark = fmt.Sprintf("Tests skipped by %s in the commit message", token)
+		return nil
+	}
+
+	if pl.Cfg.BranchHook != "" {
+		branch, hookErr := resolveBranch(ctx, pl.Cfg.BranchHook, payload.BranchName)
+		if hookErr != nil {
+			pl.Logger.Errorf("Unable to resolve branch through hook, error: %v", hookErr)
+			errRemark = "Unable to resolve branch through hook"
+			if errors.Is(hookErr, errs.ErrInvalidBranchName) {
+				errRemark = hookErr.Error()
+			}
+			err = hookErr
+			return err
+		}
+		pl.Logger.Infof("Branch %s resolved to %s by the branch hook", payload.BranchName, branch)
+		payload.BranchName = branch
+	}
+
 	coverageDir := filepath.Join(global.CodeCoveragParentDir, payload.OrgID, payload.RepoID, payload.TargetCommit)
 	pl.Logger.Infof("Cloning repo ...")
 	err = pl.GitManager.Clone(ctx, pl.Payload, oauth.Data.AccessToken)
 	if err != nil {
 		pl.Logger.Errorf("Unable to clone repo '%s': %s", payload.RepoLink, err)
 		errRemark = fmt.Sprintf("Unable to clone repo: %s", payload.RepoLink)
+		if errors.Is(err, errs.ErrPostCloneCheck) || errors.Is(err, errs.ErrUnsafeArchiveEntry) ||
+			errors.Is(err, errs.ErrCloneTooLarge) {
+			errRemark = err.Error()
+		}
 		return err
 	}
 
@@ -151,6 +214,25 @@ func (pl *Pipeline) Start(ctx context.Context) (err error) {
 
 	pl.Logger.Infof("Tas yaml: %+v", tasConfig)
 
+	parallelism, err := effectiveParallelism(tasConfig.Parallelism, os.Getenv(global.ParallelismOverrideEnv), pl.Cfg.ParallelismOverride)
+	if err != nil {
+		pl.Logger.Errorf("Unable to apply parallelism override, error: %v", err)
+		errRemark = errs.GenericUserFacingBEErrRemark
+		return err
+	}
+	if parallelism != tasConfig.Parallelism {
+		warns.Addf("Parallelism %d of tas yaml overridden to %d by %s", tasConfig.Parallelism, parallelism, global.ParallelismOverrideEnv)
+		tasConfig.Parallelism = parallelism
+	}
+
+	if tasConfig.EnvFile != "" {
+		if err = loadEnvFile(global.RepoDir, tasConfig); err != nil {
+			pl.Logger.Errorf("Unable to load env file %s, error: %v", tasConfig.EnvFile, err)
+			errRemark = fmt.Sprintf("Unable to load env file %s", tasConfig.EnvFile)
+			return err
+		}
+	}
+
 	// set testing taskID, orgID and buildID as environment variable
 	os.Setenv("TASK_ID", payload.TaskID)
 	os.Setenv("ORG_ID", payload.OrgID)
@@ -163,39 +245,43 @@ func (pl *Pipeline) Start(ctx context.Context) (err error) {
 	os.Setenv("CODE_COVERAGE_DIR", coverageDir)
 	os.Setenv("BRANCH_NAME", payload.BranchName)
 	os.Setenv("ENV", pl.Cfg.Env)
-	os.Setenv("TAS_PARALLELISM", strconv.Itoa(tasConfig.Parallelism))
+	os.Setenv(global.ParallelismEnv, strconv.Itoa(tasConfig.Parallelism))
 	os.Setenv("ENDPOINT_POST_TEST_LIST", endpointPostTestList)
 	os.Setenv("ENDPOINT_POST_TEST_RESULTS", endpointPostTestResults)
 	os.Setenv("REPO_ROOT", global.RepoDir)
 	os.Setenv("BLOCKLISTED_TESTS_FILE", global.BlocklistedFileLocation)
+	// identity of the commits and tags created by the pre-run and post-run steps
+	for k, v := range gitIdentityEnv(pl.Cfg, tasConfig) {
+		os.Setenv(k, v)
+	}
 
 	if tasConfig.NodeVersion != nil {
 		nodeVersion := tasConfig.NodeVersion.String()
-		// Running the `source` command in a directory where .nvmrc is present, exits with exitCode 3
-		// https://github.com/nvm-sh/nvm/issues/1985
-		// TODO [good-to-have]: Auto-read and install from .nvmrc file, if present
-		command := []string{"source", "/home/nucleus/.nvm/nvm.sh",
-			"&&", "nvm", "install", nodeVersion}
 		pl.Logger.Infof("Using user-defined node version: %v", nodeVersion)
-		err = pl.ExecutionManager.ExecuteInternalCommands(ctx, InstallNodeVer, command, "", nil, nil)
+		err = pl.installNodeVersion(ctx, nodeVersion)
 		if err != nil {
 			pl.Logger.Errorf("Unable to install user-defined nodeversion %v", err)
 			errRemark = errs.GenericUserFacingBEErrRemark
+			if errors.Is(err, errs.ErrInvalidNodeVersion) {
+				errRemark = err.Error()
+			}
 			return err
 		}
-		origPath := os.Getenv("PATH")
-		os.Setenv("PATH", fmt.Sprintf("/home/nucleus/.nvm/versions/node/v%s/bin:%s", nodeVersion, origPath))
 	}
 
 	if payload.CollectCoverage {
-		if err = fileutils.CreateIfNotExists(coverageDir, true); err != nil {
-			pl.Logger.Errorf("failed to create coverage directory %v", err)
+		if err = prepareCoverageDir(coverageDir); err != nil {
+			pl.Logger.Errorf("failed to prepare coverage directory %v", err)
 			errRemark = errs.GenericUserFacingBEErrRemark
+			// the error names the directory of the worker, which is not shown to the user
+			if errors.Is(err, errs.ErrCoverageDirNotWritable) {
+				errRemark = errs.CoverageDirErrRemark
+			}
 			return err
 		}
 	}
 
-	err = pl.TestBlockListService.GetBlockListedTests(ctx, tasConfig, payload.RepoID)
+	err = pl.TestBlockListService.GetBlockListedTests(ctx, tasConfig, payload.RepoID, warns)
 	if err != nil {
 		pl.Logger.Errorf("Unable to fetch blocklisted tests: %v", err)
 		errRemark = errs.GenericUserFacingBEErrRemark
@@ -203,7 +289,7 @@ func (pl *Pipeline) Start(ctx context.Context) (err error) {
 	}
 
 	// read secrets
-	secretMap, err := pl.SecretParser.GetRepoSecret(global.RepoSecretPath)
+	secretMap, err = pl.SecretParser.GetRepoSecret(global.RepoSecretPath)
 	if err != nil {
 		pl.Logger.Errorf("Error in fetching Repo secrets %v", err)
 		errRemark = errs.GenericUserFacingBEErrRemark
@@ -212,9 +298,16 @@ func (pl *Pipeline) Start(ctx context.Context) (err error) {
 
 	cacheKey := fmt.Sprintf("%s/%s/%s", payload.OrgID, payload.RepoID, tasConfig.Cache.Key)
 	// TODO:  download from cdn
-	if err = pl.CacheStore.Download(ctx, cacheKey); err != nil {
+	if err = pl.CacheStore.Download(ctx, cacheKey, tasConfig.Cache.Paths...); err != nil {
 		pl.Logger.Errorf("Unable to download cache: %v", err)
 		errRemark = errs.GenericUserFacingBEErrRemark
+		if errors.Is(err, errs.ErrCacheTimeout) {
+			errRemark = errs.CacheDownloadTimeoutErrRemark
+		} else if errors.Is(err, errs.ErrCacheExtraction) {
+			errRemark = "Unable to extract cache"
+		} else if errors.Is(err, errs.ErrCacheCorrupt) {
+			errRemark = "Cache failed checksum verification"
+		}
 		return err
 	}
 
@@ -224,6 +317,9 @@ func (pl *Pipeline) Start(ctx context.Context) (err error) {
 		if err != nil {
 			pl.Logger.Errorf("Unable to run pre-run steps %v", err)
 			errRemark = "Error occurred in pre-run steps"
+			if errors.Is(err, errs.ErrUndefinedSecret) {
+				errRemark = err.Error()
+			}
 			return err
 		}
 	}
@@ -244,10 +340,16 @@ func (pl *Pipeline) Start(ctx context.Context) (err error) {
 		}
 
 		// discover test cases
-		err = pl.TestDiscoveryService.Discover(ctx, tasConfig, pl.Payload, secretMap, diff)
+		err = pl.TestDiscoveryService.Discover(ctx, tasConfig, pl.Payload, secretMap, diff, warns)
 		if err != nil {
 			pl.Logger.Errorf("Unable to perform test discovery: %+v", err)
 			errRemark = "Error occurred in discovering tests"
+			if errors.Is(err, errs.ErrUndefinedSecret) {
+				errRemark = err.Error()
+			} else if errors.Is(err, errs.ErrDiscoveryWarmup) {
+				errRemark = "Discovery warmup command failed"
+			}
+			errStatus = pl.classifyFailure(err)
 			return err
 		}
 		// mark status as passed
@@ -257,25 +359,36 @@ func (pl *Pipeline) Start(ctx context.Context) (err error) {
 
 	if pl.Cfg.ExecuteMode {
 		// execute test cases
-		executionResult, err := pl.TestExecutionService.Run(ctx, tasConfig, pl.Payload, coverageDir, secretMap)
+		executionResult, err := pl.TestExecutionService.Run(ctx, tasConfig, pl.Payload, coverageDir, secretMap, warns)
 		if err != nil {
 			pl.Logger.Infof("Unable to perform test execution: %v", err)
 			errRemark = "Error occurred in executing tests"
+			if errors.Is(err, errs.ErrUndefinedSecret) {
+				errRemark = err.Error()
+			}
+			errStatus = pl.classifyFailure(err)
 			return err
 		}
 
+		executionResult.Metadata = mergeMetadata(payload.Metadata, executionResult.Metadata)
+		executionResult.Warnings = warns.List()
+		maskExecutionResult(executionResult, secretMap)
 		if err = pl.sendStats(*executionResult); err != nil {
 			pl.Logger.Errorf("error while sending test reports %v", err)
 			errRemark = errs.GenericUserFacingBEErrRemark
 			return err
 		}
-		taskPayload.Status = Passed
-		for i := 0; i < len(executionResult.TestPayload); i++ {
-			testResult := &executionResult.TestPayload[i]
-			if testResult.Status == "failed" {
-				taskPayload.Status = Failed
-				break
+		taskPayload.Status, err = findTaskPayloadStatus(executionResult.TestPayload, pl.Cfg.EmptySuitePolicy, pl.Cfg.UnknownStatusPolicy)
+		if err != nil {
+			pl.Logger.Errorf("Unable to determine the task status: %v", err)
+			errRemark = errs.GenericUserFacingBEErrRemark
+			if errors.Is(err, errs.ErrUnknownTestStatus) {
+				errRemark = err.Error()
 			}
+			return err
+		}
+		if len(executionResult.TestPayload) == 0 {
+			pl.Logger.Infof("No tests were executed, marking task as %s", taskPayload.Status)
 		}
 
 		if tasConfig.Postrun != nil {
@@ -283,14 +396,25 @@ func (pl *Pipeline) Start(ctx context.Context) (err error) {
 			err = pl.ExecutionManager.ExecuteUserCommands(ctx, PostRun, payload, tasConfig.Postrun, secretMap)
 			if err != nil {
 				pl.Logger.Errorf("Unable to run post-run steps %v", err)
-				errRemark = "Error occurred in pre-run steps"
+				errRemark = errs.PostRunErrRemark
+				if errors.Is(err, errs.ErrUndefinedSecret) {
+					errRemark = err.Error()
+				}
 				return err
 			}
 		}
 	}
+	if pl.Cfg.StrictSecrets {
+		if unused := pl.SecretParser.UnusedSecrets(secretMap); len(unused) > 0 {
+			warns.Addf("Secrets defined but never referenced: %s", strings.Join(unused, ", "))
+		}
+	}
 	if err = pl.CacheStore.Upload(ctx, cacheKey, tasConfig.Cache.Paths...); err != nil {
 		pl.Logger.Errorf("Unable to upload cache: %v", err)
 		errRemark = errs.GenericUserFacingBEErrRemark
+		if errors.Is(err, errs.ErrCacheTimeout) {
+			errRemark = errs.CacheUploadTimeoutErrRemark
+		}
 		return err
 	}
 	pl.Logger.Debugf("Cache uploaded successfully")
@@ -300,30 +424,411 @@ func (pl *Pipeline) Start(ctx context.Context) (err error) {
 }
 
 func (pl *Pipeline) sendStats(payload ExecutionResult) error {
-	reqBody, err := json.Marshal(payload)
-	if err != nil {
-		pl.Logger.Errorf("failed to marshal request body %v", err)
-		return err
+	if pl.Cfg.Offline {
+		reqBody, err := json.Marshal(payload)
+		if err != nil {
+			pl.Logger.Errorf("failed to marshal request body %v", err)
+			return err
+		}
+		reportPath := filepath.Join(pl.Cfg.OfflineDir, global.OfflineReportFile)
+		pl.Logger.Infof("offline mode, writing test reports to %s", reportPath)
+		if err := utils.CreateDirectory(pl.Cfg.OfflineDir); err != nil {
+			return err
+		}
+		return utils.WriteFileToDirectory(pl.Cfg.OfflineDir, global.OfflineReportFile, reqBody)
+	}
+
+	sampled := payload
+	// the sampling is configured by the operator, the orchestrator can only turn it off for a build
+	if enabled, ok := pl.Payload.FeatureOverride(global.FeatureSampledReports); enabled || !ok {
+		sampled = samplePassedResults(payload, pl.Cfg.PassedResultSample)
 	}
+	version := global.ReportSchemaVersion
+	for {
+		report := sampled
+		if version < 3 {
+			// an older neuron would take the sample for all the passed tests
+			report = payload
+		}
+		statusCode, backendVersion, err := pl.postReport(downgradeReport(report, version), version)
+		if err != nil {
+			pl.Logger.Errorf("error while sending reports %v", err)
+			return err
+		}
+		if statusCode == http.StatusOK {
+			return nil
+		}
+		// an older neuron rejects the fields it does not know and advertises the schema it supports
+		if backendVersion > 0 && backendVersion < version {
+			pl.Logger.Infof("neuron supports report schema version %d, downgrading the report from version %d", backendVersion, version)
+			version = backendVersion
+			continue
+		}
+		pl.Logger.Errorf("error while sending reports, non 200 status")
+		return errors.New("non 200 status")
+	}
+}
 
-	req, err := http.NewRequest(http.MethodPost, endpointNeuronReport, bytes.NewBuffer(reqBody))
+// postReport sends the report declaring its schema version, it returns the response status along
+// with the schema version advertised by neuron, which is 0 if the response has none.
+func (pl *Pipeline) postReport(payload ExecutionResult, version int) (statusCode, backendVersion int, err error) {
+	// stream the encoded report to the request body instead of buffering it in memory
+	reqBody, bodyWriter := io.Pipe()
+	defer reqBody.Close()
+	go func() {
+		var w io.Writer = bodyWriter
+		var gz *gzip.Writer
+		if pl.Cfg.GzipReports {
+			gz = gzip.NewWriter(bodyWriter)
+			w = gz
+		}
+		err := json.NewEncoder(w).Encode(payload)
+		if err == nil && gz != nil {
+			err = gz.Close()
+		}
+		if err != nil {
+			pl.Logger.Errorf("failed to encode request body %v", err)
+		}
+		bodyWriter.CloseWithError(err)
+	}()
+
+	req, err := http.NewRequest(http.MethodPost, endpointNeuronReport, reqBody)
 	if err != nil {
 		pl.Logger.Errorf("failed to create new request %v", err)
-		return err
+		return 0, 0, err
+	}
+	utils.AddNeuronHeaders(req)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(global.ReportSchemaHeader, strconv.Itoa(version))
+	if pl.Cfg.GzipReports {
+		req.Header.Set("Content-Encoding", "gzip")
 	}
 
 	resp, err := pl.HttpClient.Do(req)
-
 	if err != nil {
-		pl.Logger.Errorf("error while sending reports %v", err)
-		return err
+		return 0, 0, err
 	}
-
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		pl.Logger.Errorf("error while sending reports, non 200 status")
-		return errors.New("non 200 status")
+	// an invalid version is treated as not advertised
+	backendVersion, _ = strconv.Atoi(resp.Header.Get(global.ReportSchemaHeader))
+	return resp.StatusCode, backendVersion, nil
+}
+
+// samplePassedResults returns the report with at most limit of its passed test results, evenly spread
+// over them, along with their total count. The other results are all kept. A non-positive limit keeps
+// all the results. The results are copied, the report of the caller is left unchanged.
+func samplePassedResults(payload ExecutionResult, limit int) ExecutionResult {
+	if limit <= 0 {
+		return payload
+	}
+	var passed int
+	for i := range payload.TestPayload {
+		if payload.TestPayload[i].Status == string(Passed) {
+			passed++
+		}
+	}
+	if passed <= limit {
+		return payload
+	}
+	testResults := make([]TestPayload, 0, len(payload.TestPayload)-passed+limit)
+	// the n-th passed result is kept when it reaches the next of the limit evenly spaced positions
+	var n, kept int
+	for i := range payload.TestPayload {
+		if payload.TestPayload[i].Status != string(Passed) {
+			testResults = append(testResults, payload.TestPayload[i])
+			continue
+		}
+		if kept < limit && n == kept*passed/limit {
+			testResults = append(testResults, payload.TestPayload[i])
+			kept++
+		}
+		n++
+	}
+	payload.TestPayload = testResults
+	payload.PassedSampled = true
+	payload.PassedTotal = passed
+	return payload
+}
+
+// downgradeReport returns the report without the fields which are newer than the schema version.
+// The results are copied, the report of the caller is left unchanged.
+func downgradeReport(payload ExecutionResult, version int) ExecutionResult {
+	if version < 4 {
+		payload.Environment = nil
+	}
+	if version >= 2 {
+		return payload
+	}
+	payload.Metadata = nil
+	payload.RunnerVersion = ""
+	payload.Warnings = nil
+	payload.ExecutionSeed = 0
+	testResults := make([]TestPayload, len(payload.TestPayload))
+	for i := range payload.TestPayload {
+		testResults[i] = payload.TestPayload[i]
+		testResults[i].Attempts = 0
+		testResults[i].Output = ""
+	}
+	payload.TestPayload = testResults
+	return payload
+}
+
+// knownTestStatuses are the statuses of the test results reported by the runners
+var knownTestStatuses = map[string]bool{
+	string(Passed):  true,
+	string(Failed):  true,
+	string(Skipped): true,
+	"pending":       true,
+	"blocklisted":   true,
+}
+
+// findTaskPayloadStatus returns the status of the task from its test results, the status of a task
+// without any test results depends on the empty suite policy. A failed test fails the task, otherwise
+// the results with an unknown or empty status are handled by the unknown status policy.
+func findTaskPayloadStatus(results []TestPayload, emptySuitePolicy, unknownStatusPolicy string) (Status, error) {
+	if len(results) == 0 {
+		switch emptySuitePolicy {
+		case global.EmptySuitePassed:
+			return Passed, nil
+		case global.EmptySuiteNoTests:
+			return NoTests, nil
+		case global.EmptySuiteFailed:
+			return Failed, nil
+		default:
+			return "", fmt.Errorf("invalid empty suite policy %q", emptySuitePolicy)
+		}
+	}
+	var unknown *TestPayload
+	for i := range results {
+		if results[i].Status == string(Failed) {
+			return Failed, nil
+		}
+		if unknown == nil && !knownTestStatuses[results[i].Status] {
+			unknown = &results[i]
+		}
+	}
+	if unknown == nil {
+		return Passed, nil
+	}
+	switch unknownStatusPolicy {
+	case global.UnknownStatusError, "":
+		return "", fmt.Errorf("%w %q of test %s", errs.ErrUnknownTestStatus, unknown.Status, unknown.TestID)
+	case global.UnknownStatusFailed:
+		return Failed, nil
+	case global.UnknownStatusIgnore:
+		return Passed, nil
+	default:
+		return "", fmt.Errorf("invalid unknown status policy %q", unknownStatusPolicy)
+	}
+}
+
+// validateStatusPolicies checks the policies of findTaskPayloadStatus
+func validateStatusPolicies(emptySuitePolicy, unknownStatusPolicy string) error {
+	switch emptySuitePolicy {
+	case global.EmptySuitePassed, global.EmptySuiteNoTests, global.EmptySuiteFailed:
+	default:
+		return fmt.Errorf("invalid empty suite policy %q", emptySuitePolicy)
+	}
+	switch unknownStatusPolicy {
+	case global.UnknownStatusError, global.UnknownStatusFailed, global.UnknownStatusIgnore, "":
+	default:
+		return fmt.Errorf("invalid unknown status policy %q", unknownStatusPolicy)
+	}
+	return nil
+}
+
+// prepareCoverageDir creates the coverage directory and verifies that the tests can write to it,
+// otherwise the coverage is lost silently and only noticed when it is merged
+func prepareCoverageDir(dir string) error {
+	if err := fileutils.CreateIfNotExists(dir, true); err != nil {
+		return fmt.Errorf("%w %s: %v", errs.ErrCoverageDirNotWritable, dir, err)
+	}
+	if err := fileutils.CheckWritableDir(dir); err != nil {
+		return fmt.Errorf("%w %s: %v", errs.ErrCoverageDirNotWritable, dir, err)
+	}
+	return nil
+}
+
+// effectiveParallelism applies the parallelism override of the operator to the parallelism of the
+// tas configuration file. Depending on mode, the override caps or replaces the configured value,
+// an empty override leaves it unchanged.
+func effectiveParallelism(configured int, override, mode string) (int, error) {
+	if override == "" {
+		return configured, nil
+	}
+	value, err := strconv.Atoi(override)
+	if err != nil || value < 1 {
+		return 0, fmt.Errorf("%w: %s=%q is not a positive integer", errs.ErrInvalidParallelism, global.ParallelismOverrideEnv, override)
+	}
+	switch mode {
+	case global.ParallelismOverrideCap:
+		if configured > value {
+			return value, nil
+		}
+		return configured, nil
+	case global.ParallelismOverrideReplace:
+		return value, nil
+	default:
+		return 0, fmt.Errorf("%w: unknown mode %q", errs.ErrInvalidParallelism, mode)
+	}
+}
+
+// gitIdentityEnv returns the git env vars of the identity of the user's commands. The identity of
+// the tas configuration file takes precedence over the one of the config.
+func gitIdentityEnv(cfg *config.NucleusConfig, tasConfig *TASConfig) map[string]string {
+	name, email := cfg.GitUserName, cfg.GitUserEmail
+	if tasConfig.GitIdentity != nil {
+		if tasConfig.GitIdentity.Name != "" {
+			name = tasConfig.GitIdentity.Name
+		}
+		if tasConfig.GitIdentity.Email != "" {
+			email = tasConfig.GitIdentity.Email
+		}
+	}
+	env := make(map[string]string)
+	if name != "" {
+		env["GIT_AUTHOR_NAME"] = name
+		env["GIT_COMMITTER_NAME"] = name
+	}
+	if email != "" {
+		env["GIT_AUTHOR_EMAIL"] = email
+		env["GIT_COMMITTER_EMAIL"] = email
+	}
+	return env
+}
+
+// skipTestsToken returns the first of the tokens found in the message of the target commit,
+// ignoring case, or an empty string if the tests should run.
+func skipTestsToken(payload *Payload, tokens []string) string {
+	if len(payload.Commits) == 0 {
+		return ""
+	}
+	// the target commit is usually the last one
+	message := payload.Commits[len(payload.Commits)-1].Message
+	for i := range payload.Commits {
+		if payload.Commits[i].Sha == payload.TargetCommit {
+			message = payload.Commits[i].Message
+			break
+		}
+	}
+	message = strings.ToLower(message)
+	for _, token := range tokens {
+		if token != "" && strings.Contains(message, strings.ToLower(token)) {
+			return token
+		}
+	}
+	return ""
+}
+
+// maskTaskPayload sets the remark and warnings of the task status with the secrets masked,
+// as they can contain the output of commands or error messages
+func maskTaskPayload(taskPayload *TaskPayload, warnings []string, secretData map[string]string) {
+	taskPayload.Remark = logstream.MaskString(taskPayload.Remark, secretData)
+	taskPayload.Warnings = make([]string, 0, len(warnings))
+	for _, warning := range warnings {
+		taskPayload.Warnings = append(taskPayload.Warnings, logstream.MaskString(warning, secretData))
+	}
+}
+
+// mergeMetadata returns the build metadata along with the metadata reported by the execution
+func mergeMetadata(build, execution map[string]string) map[string]string {
+	if len(execution) == 0 {
+		return build
+	}
+	merged := make(map[string]string, len(build)+len(execution))
+	for k, v := range build {
+		merged[k] = v
+	}
+	for k, v := range execution {
+		merged[k] = v
+	}
+	return merged
+}
+
+// maskExecutionResult masks the secrets and the redacted paths in the strings of the report which
+// come from the user's tests and commands. The test output is masked when it is captured.
+func maskExecutionResult(result *ExecutionResult, secretData map[string]string) {
+	for i := range result.Warnings {
+		result.Warnings[i] = logstream.MaskString(result.Warnings[i], secretData)
+	}
+	for i := range result.TestPayload {
+		result.TestPayload[i].Detail = logstream.MaskString(result.TestPayload[i].Detail, secretData)
+	}
+}
+
+// preserveWorkspace uploads a snapshot of the workspace of the failed task for inspection.
+// A failure is only logged, the task has already failed.
+func (pl *Pipeline) preserveWorkspace(ctx context.Context, payload *Payload) {
+	blobPath := fmt.Sprintf("%s/%s/%s/%s", payload.OrgID, payload.BuildID, payload.TaskID, global.WorkspaceSnapshotFile)
+	pl.Logger.Infof("Uploading workspace snapshot to %s", blobPath)
+	if err := pl.CacheStore.UploadWorkspace(ctx, blobPath); err != nil {
+		pl.Logger.Errorf("failed to upload workspace snapshot, error: %v", err)
+	}
+}
+
+// updateFinalStatus sends the terminal status of the task. A failure is only logged,
+// exiting here would hide the actual outcome of the task.
+func (pl *Pipeline) updateFinalStatus(taskPayload *TaskPayload) {
+	if err := pl.Task.UpdateStatus(taskPayload); err != nil {
+		pl.Logger.Errorf("failed to update final task status to %s: %v", taskPayload.Status, err)
+	}
+}
+
+// nvmSuccessExitCode reports whether the exit code of the node version install is configured as success
+func (pl *Pipeline) nvmSuccessExitCode(code int) bool {
+	for _, c := range pl.Cfg.NvmSuccessExitCodes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// classifyFailure returns the status of the task whose discovery or execution failed with err,
+// which is an error unless a failure class matches
+func (pl *Pipeline) classifyFailure(err error) Status {
+	status, pattern, ok := classifyFailure(err, pl.failureClasses)
+	if !ok {
+		return Error
+	}
+	pl.Logger.Infof("Failure matched the pattern %q, marking task as %s", pattern, status)
+	return status
+}
+
+// nodeVersionPattern matches the semantic versions, optionally prefixed with v
+var nodeVersionPattern = regexp.MustCompile(`^v?[0-9]+\.[0-9]+\.[0-9]+(-[0-9A-Za-z.-]+)?(\+[0-9A-Za-z.-]+)?$`)
+
+// installNodeVersion installs the node version through nvm, unless it is already present,
+// and prepends its binaries to the PATH.
+func (pl *Pipeline) installNodeVersion(ctx context.Context, nodeVersion string) error {
+	// the version is interpolated in a shell command
+	if !nodeVersionPattern.MatchString(nodeVersion) {
+		return fmt.Errorf("%w %q", errs.ErrInvalidNodeVersion, nodeVersion)
+	}
+	nodeVersion = strings.TrimPrefix(nodeVersion, "v")
+	binPath := filepath.Join(nvmDir, "versions", "node", "v"+nodeVersion, "bin")
+	if _, err := os.Stat(binPath); err == nil {
+		pl.Logger.Infof("Node version %s is already installed, skipping nvm install", nodeVersion)
+	} else {
+		pl.Logger.Infof("Node version %s is not installed, installing through nvm", nodeVersion)
+		// TODO [good-to-have]: Auto-read and install from .nvmrc file, if present
+		// --no-use keeps sourcing nvm from switching to the version of a .nvmrc, which exits with code 3
+		// when that version is missing and would skip the install
+		command := []string{"source", nvmDir + "/nvm.sh", "--no-use",
+			"&&", "nvm", "install", nodeVersion}
+		if err := pl.ExecutionManager.ExecuteInternalCommands(ctx, InstallNodeVer, command, "", nil, nil); err != nil {
+			var exitErr *exec.ExitError
+			if !errors.As(err, &exitErr) || !pl.nvmSuccessExitCode(exitErr.ExitCode()) {
+				return err
+			}
+			pl.Logger.Warnf("nvm exited with code %d, treating it as success", exitErr.ExitCode())
+		}
+		// nvm install also exits with code 3 for an unknown version
+		if _, err := os.Stat(binPath); err != nil {
+			return fmt.Errorf("node version %s is not installed after nvm install: %w", nodeVersion, err)
+		}
 	}
+	os.Setenv("PATH", fmt.Sprintf("%s:%s", binPath, os.Getenv("PATH")))
 	return nil
 }
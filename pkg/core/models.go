@@ -83,6 +83,7 @@ type CommitChangeList struct {
 
 // Payload defines structure of payload
 type Payload struct {
+	SchemaVersion              int                `json:"schema_version"`
 	RepoSlug                   string             `json:"repo_slug"`
 	RepoLink                   string             `json:"repo_link"`
 	BuildTargetCommit          string             `json:"build_target_commit"`
@@ -106,6 +107,24 @@ type Payload struct {
 	ParentCommitCoverageExists bool               `json:"parent_commit_coverage_exists"`
 	LicenseTier                Tier               `json:"license_tier"`
 	CollectCoverage            bool               `json:"collect_coverage"`
+	Metadata                   map[string]string  `json:"metadata"`
+	DiffBaseCommit             string             `json:"diff_base_commit"`
+	FeatureFlags               map[string]bool    `json:"feature_flags"`
+}
+
+// FeatureEnabled reports whether the feature flag is on for the run, unknown flags are off
+func (p *Payload) FeatureEnabled(name string) bool {
+	return p != nil && p.FeatureFlags[name]
+}
+
+// FeatureOverride returns the value of the feature flag and whether it is set for the run, so that
+// the orchestrator can turn an explicitly configured behavior off or on for a build
+func (p *Payload) FeatureOverride(name string) (enabled, ok bool) {
+	if p == nil {
+		return false, false
+	}
+	enabled, ok = p.FeatureFlags[name]
+	return enabled, ok
 }
 
 // Pipeline defines all attributes of Pipeline
@@ -128,6 +147,8 @@ type Pipeline struct {
 	Task                 Task
 	SecretParser         SecretParser
 	HttpClient           http.Client
+	// failureClasses set the status of the tasks whose discovery or execution failed
+	failureClasses []failureClass
 }
 
 // ExecutionResult represents the request body for test and test suite execution
@@ -139,6 +160,24 @@ type ExecutionResult struct {
 	CommitID         string             `json:"commitID"`
 	TestPayload      []TestPayload      `json:"testResults"`
 	TestSuitePayload []TestSuitePayload `json:"testSuiteResults"`
+	Metadata         map[string]string  `json:"metadata,omitempty"`
+	RunnerVersion    string             `json:"runnerVersion,omitempty"`
+	Warnings         []string           `json:"warnings,omitempty"`
+	ExecutionSeed    int64              `json:"executionSeed,omitempty"`
+	PassedSampled    bool               `json:"passedSampled,omitempty"`
+	PassedTotal      int                `json:"passedTotal,omitempty"`
+	Environment      *Environment       `json:"environment,omitempty"`
+}
+
+// Environment is the snapshot of the environment the tests were executed in, to reproduce the run.
+// The secret values of the env are masked.
+type Environment struct {
+	Env              map[string]string `json:"env"`
+	NodeVersion      string            `json:"nodeVersion"`
+	Framework        string            `json:"framework"`
+	FrameworkVersion string            `json:"frameworkVersion"`
+	OS               string            `json:"os"`
+	Arch             string            `json:"arch"`
 }
 
 // TestPayload represents the request body for test execution
@@ -164,6 +203,8 @@ type TestPayload struct {
 	StartTime       time.Time          `json:"start_time"`
 	EndTime         time.Time          `json:"end_time"`
 	Stats           []TestProcessStats `json:"stats"`
+	Attempts        int                `json:"attempts,omitempty"`
+	Output          string             `json:"output,omitempty"`
 }
 
 // TestSuitePayload represents the request body for test suite execution
@@ -199,6 +240,8 @@ const (
 	Aborted    Status = "aborted"
 	Passed     Status = "passed"
 	Error      Status = "error"
+	Skipped    Status = "skipped"
+	NoTests    Status = "no-tests"
 )
 
 // ParserStatus repersent information related to each parsing
@@ -223,19 +266,21 @@ type ParserResponse struct {
 
 // TaskPayload repersent task response given by nucleus to neuron
 type TaskPayload struct {
-	TaskID      string    `json:"task_id"`
-	Status      Status    `json:"status"`
-	RepoSlug    string    `json:"repo_slug"`
-	RepoLink    string    `json:"repo_link"`
-	RepoID      string    `json:"repo_id"`
-	OrgID       string    `json:"org_id"`
-	GitProvider string    `json:"git_provider"`
-	CommitID    string    `json:"commit_id,omitempty"`
-	BuildID     string    `json:"build_id"`
-	StartTime   time.Time `json:"start_time"`
-	EndTime     time.Time `json:"end_time,omitempty"`
-	Remark      string    `json:"remark,omitempty"`
-	Type        TaskType  `json:"type"`
+	TaskID       string          `json:"task_id"`
+	Status       Status          `json:"status"`
+	RepoSlug     string          `json:"repo_slug"`
+	RepoLink     string          `json:"repo_link"`
+	RepoID       string          `json:"repo_id"`
+	OrgID        string          `json:"org_id"`
+	GitProvider  string          `json:"git_provider"`
+	CommitID     string          `json:"commit_id,omitempty"`
+	BuildID      string          `json:"build_id"`
+	StartTime    time.Time       `json:"start_time"`
+	EndTime      time.Time       `json:"end_time,omitempty"`
+	Remark       string          `json:"remark,omitempty"`
+	Type         TaskType        `json:"type"`
+	Warnings     []string        `json:"warnings,omitempty"`
+	FeatureFlags map[string]bool `json:"feature_flags,omitempty"`
 }
 
 //CoverageMainfest for post processing coverage job
@@ -243,6 +288,7 @@ type CoverageMainfest struct {
 	Removedfiles      []string           `json:"removed_files"`
 	AllFilesExecuted  bool               `json:"all_files_executed"`
 	CoverageThreshold *CoverageThreshold `json:"coverage_threshold,omitempty"`
+	CoverageExclude   []string           `json:"coverage_exclude,omitempty"`
 }
 
 const (
@@ -287,6 +333,17 @@ type TASConfig struct {
 	Tier              Tier               `yaml:"tier" validate:"oneof=xsmall small medium large xlarge"`
 	NodeVersion       *semver.Version    `yaml:"nodeVersion"`
 	ContainerImage    string             `yaml:"containerImage"`
+	CleanPaths        []string           `yaml:"cleanPaths"`
+	DiscoverCommand   string             `yaml:"discoverCommand"`
+	CoverageExclude   []string           `yaml:"coverageExclude"`
+	ArtifactPaths     []string           `yaml:"artifactPaths"`
+	SkipFrameworkEnv  bool               `yaml:"skipFrameworkEnv"`
+	GitIdentity       *GitIdentity       `yaml:"gitIdentity" validate:"omitempty"`
+	ExecConcurrency   int                `yaml:"execConcurrency" validate:"omitempty,min=1,max=64"`
+	ExecutionOrder    *ExecutionOrder    `yaml:"executionOrder" validate:"omitempty"`
+	BlockNetwork      bool               `yaml:"blockNetwork"`
+	EnvFile           string             `yaml:"envFile"`
+	DiscoveryWarmup   string             `yaml:"discoveryWarmup" validate:"omitempty,max=4096"`
 }
 
 //CoverageThreshold reprents the code coverage threshold
@@ -298,6 +355,25 @@ type CoverageThreshold struct {
 	PerFile    bool    `yaml:"perFile" json:"perFile"`
 }
 
+// GitIdentity represents the identity of the git commits and tags created by the user's commands
+type GitIdentity struct {
+	Name  string `yaml:"name"`
+	Email string `yaml:"email" validate:"omitempty,email"`
+}
+
+// NetworkAttempt represents an outbound connection refused while the network of the tests is blocked
+type NetworkAttempt struct {
+	Host string
+	Time time.Time
+}
+
+// ExecutionOrder represents the order in which the tests are executed. A zero seed of the
+// random order is replaced by a generated one.
+type ExecutionOrder struct {
+	Strategy string `yaml:"strategy" validate:"oneof=declared alphabetical random"`
+	Seed     int64  `yaml:"seed" validate:"min=-2147483648,max=2147483647"`
+}
+
 // Cache represents the user's cached directories
 type Cache struct {
 	Key   string   `yaml:"key" validate:"required"`
@@ -0,0 +1,37 @@
+package core
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/LambdaTest/synapse/pkg/lumber"
+)
+
+// Warnings collects the non-fatal conditions of a task which are reported to the user
+// along with the test results and the task status, instead of being only logged.
+type Warnings struct {
+	logger lumber.Logger
+	mu     sync.Mutex
+	list   []string
+}
+
+// NewWarnings returns an empty Warnings logging each warning with logger
+func NewWarnings(logger lumber.Logger) *Warnings {
+	return &Warnings{logger: logger}
+}
+
+// Addf logs the warning and records it for the report
+func (w *Warnings) Addf(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	w.logger.Warnf("%s", msg)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.list = append(w.list, msg)
+}
+
+// List returns the warnings collected so far
+func (w *Warnings) List() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]string(nil), w.list...)
+}
@@ -0,0 +1,140 @@
+package diffmanager
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/LambdaTest/synapse/pkg/core"
+)
+
+const devNull = "/dev/null"
+
+// readDiffFile parses the unified diff at path into the changed files.
+func (dm *diffManager) readDiffFile(path string) (map[string]int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return dm.parseUnifiedDiff(f)
+}
+
+// parseUnifiedDiff parses a unified diff, as generated by `git diff` or `diff -u`, into the changed files.
+// Hunks are skipped using their line counts, so that the content of the files is never mistaken for headers.
+func (dm *diffManager) parseUnifiedDiff(r io.Reader) (map[string]int, error) {
+	m := make(map[string]int)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	var oldLines, newLines int
+	// paths of the current `diff --git` header, needed for files without any hunks eg. empty new files
+	var oldPath, newPath string
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := scanner.Text()
+		if oldLines > 0 || newLines > 0 {
+			switch {
+			case strings.HasPrefix(line, "\\"):
+				// "\ No newline at end of file"
+			case strings.HasPrefix(line, "-"):
+				oldLines--
+			case strings.HasPrefix(line, "+"):
+				newLines--
+			default:
+				oldLines--
+				newLines--
+			}
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			paths := strings.SplitN(line[len("diff --git "):], " b/", 2)
+			if len(paths) != 2 {
+				return nil, fmt.Errorf("invalid diff header at line %d", lineNum)
+			}
+			oldPath, newPath = diffPath(paths[0]), paths[1]
+		case strings.HasPrefix(line, "new file mode "):
+			dm.updateWithOr(m, newPath, core.FileAdded)
+		case strings.HasPrefix(line, "deleted file mode "):
+			dm.updateWithOr(m, oldPath, core.FileRemoved)
+		case strings.HasPrefix(line, "@@ "):
+			var err error
+			if oldLines, newLines, err = parseHunkHeader(line); err != nil {
+				return nil, fmt.Errorf("invalid hunk header at line %d: %w", lineNum, err)
+			}
+		case strings.HasPrefix(line, "--- "):
+			if path := diffPath(line[4:]); path != "" {
+				dm.updateWithOr(m, path, core.FileRemoved)
+			}
+		case strings.HasPrefix(line, "+++ "):
+			if path := diffPath(line[4:]); path != "" {
+				dm.updateWithOr(m, path, core.FileAdded)
+			}
+		case strings.HasPrefix(line, "rename from "):
+			dm.updateWithOr(m, line[len("rename from "):], core.FileRemoved)
+		case strings.HasPrefix(line, "rename to "):
+			dm.updateWithOr(m, line[len("rename to "):], core.FileAdded)
+		case strings.HasPrefix(line, "Binary files ") && strings.HasSuffix(line, " differ"):
+			// Binary files a/x and b/x differ
+			paths := strings.SplitN(strings.TrimSuffix(line[len("Binary files "):], " differ"), " and ", 2)
+			if len(paths) != 2 {
+				return nil, fmt.Errorf("invalid binary file marker at line %d", lineNum)
+			}
+			if path := diffPath(paths[0]); path != "" {
+				dm.updateWithOr(m, path, core.FileRemoved)
+			}
+			if path := diffPath(paths[1]); path != "" {
+				dm.updateWithOr(m, path, core.FileAdded)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// parseHunkHeader returns the number of old and new lines of the hunk, eg. "@@ -1,5 +1,6 @@".
+func parseHunkHeader(line string) (oldLines, newLines int, err error) {
+	fields := strings.Fields(line)
+	if len(fields) < 3 || !strings.HasPrefix(fields[1], "-") || !strings.HasPrefix(fields[2], "+") {
+		return 0, 0, fmt.Errorf("malformed hunk header %q", line)
+	}
+	if oldLines, err = hunkRangeLength(fields[1][1:]); err != nil {
+		return 0, 0, err
+	}
+	if newLines, err = hunkRangeLength(fields[2][1:]); err != nil {
+		return 0, 0, err
+	}
+	return oldLines, newLines, nil
+}
+
+// hunkRangeLength returns the length of the range "start,length", the length defaults to 1.
+func hunkRangeLength(r string) (int, error) {
+	idx := strings.Index(r, ",")
+	if idx == -1 {
+		if _, err := strconv.Atoi(r); err != nil {
+			return 0, err
+		}
+		return 1, nil
+	}
+	return strconv.Atoi(r[idx+1:])
+}
+
+// diffPath strips the timestamp and the a/ or b/ prefix from a path in the diff header,
+// it returns an empty path for /dev/null.
+func diffPath(path string) string {
+	if idx := strings.Index(path, "\t"); idx != -1 {
+		path = path[:idx]
+	}
+	if path == devNull {
+		return ""
+	}
+	if strings.HasPrefix(path, "a/") || strings.HasPrefix(path, "b/") {
+		return path[2:]
+	}
+	return path
+}
@@ -0,0 +1,81 @@
+package diffmanager
+
+import (
+	"context"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/LambdaTest/synapse/config"
+	"github.com/LambdaTest/synapse/pkg/core"
+	"github.com/LambdaTest/synapse/pkg/lumber"
+	"github.com/stretchr/testify/assert"
+)
+
+func newDiffManager(cfg *config.NucleusConfig) *diffManager {
+	logger, err := lumber.NewLogger(lumber.LoggingConfig{EnableConsole: true}, true, lumber.InstanceZapLogger)
+	if err != nil {
+		log.Fatalf("Could not instantiate logger %s", err.Error())
+	}
+	return NewDiffManager(cfg, logger)
+}
+
+func TestGetChangedFilesFromDiffFile(t *testing.T) {
+	dm := newDiffManager(&config.NucleusConfig{DiffFile: "testdata/sample.patch"})
+	// the provider is never called when the diff file is set
+	payload := &core.Payload{GitProvider: core.GitHub, EventType: core.EventPush, RepoLink: "https://invalid.example.com/org/repo"}
+	got, err := dm.GetChangedFiles(context.TODO(), payload, "")
+	if err != nil {
+		t.Fatalf("GetChangedFiles() error = %v", err)
+	}
+	want := map[string]int{
+		"src/math.js":       core.FileModified,
+		"test/math.spec.js": core.FileAdded,
+		"src/legacy.js":     core.FileRemoved,
+		".gitkeep":          core.FileAdded,
+		"src/old-name.js":   core.FileRemoved,
+		"src/new-name.js":   core.FileAdded,
+		"assets/logo.png":   core.FileModified,
+		"assets/icon.png":   core.FileAdded,
+	}
+	assert.Equal(t, want, got)
+
+	dm = newDiffManager(&config.NucleusConfig{DiffFile: "testdata/missing.patch"})
+	_, err = dm.GetChangedFiles(context.TODO(), payload, "")
+	assert.NotNil(t, err)
+}
+
+func TestParseUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name    string
+		diff    string
+		want    map[string]int
+		wantErr bool
+	}{
+		{
+			name: "plain diff -u",
+			diff: "--- src/a.js\t2022-01-01 10:00:00\n+++ src/a.js\t2022-01-02 10:00:00\n@@ -1 +1 @@\n-a\n+b\n",
+			want: map[string]int{"src/a.js": core.FileModified},
+		},
+		{
+			name: "empty diff",
+			diff: "",
+			want: map[string]int{},
+		},
+		{
+			name:    "malformed hunk header",
+			diff:    "--- a/src/a.js\n+++ b/src/a.js\n@@ -x +1 @@\n",
+			wantErr: true,
+		},
+	}
+	dm := newDiffManager(&config.NucleusConfig{})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := dm.parseUnifiedDiff(strings.NewReader(tt.diff))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseUnifiedDiff() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -201,8 +201,34 @@ func (dm *diffManager) GetChangedFiles(ctx context.Context, payload *core.Payloa
 	// map to store file and type of change (added, removed, modified)
 	var m map[string]int
 
+	if dm.cfg.DiffFile != "" {
+		m, err := dm.readDiffFile(dm.cfg.DiffFile)
+		if err != nil {
+			dm.logger.Errorf("failed to parse diff file %s error: %v", dm.cfg.DiffFile, err)
+			return nil, err
+		}
+		return m, nil
+	}
+
 	var diff []byte
 	var err error
+	if payload.DiffBaseCommit != "" {
+		// an explicit base takes precedence over the base derived from the event,
+		// the compare api is used for pull requests as well
+		diff, err = dm.getCommitDiff(payload.GitProvider, payload.RepoLink, cloneToken, payload.DiffBaseCommit, payload.TargetCommit)
+		if err != nil {
+			dm.logger.Errorf("failed to get diff against base commit %s for gitprovider: %s error: %v",
+				payload.DiffBaseCommit, payload.GitProvider, err)
+			return nil, err
+		}
+		m, err = dm.parseGitDiff(payload.GitProvider, core.EventPush, diff)
+		if err != nil {
+			dm.logger.Errorf("failed to parse gitdiff for gitprovider: %s error: %v", payload.GitProvider, err)
+			return nil, err
+		}
+		return m, nil
+	}
+
 	if payload.EventType == core.EventPullRequest {
 		diff, err = dm.getPRDiff(payload.GitProvider, payload.RepoLink, payload.PullRequestNumber, cloneToken)
 		if err != nil {
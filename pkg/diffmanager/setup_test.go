@@ -0,0 +1,58 @@
+package diffmanager
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/LambdaTest/synapse/config"
+	"github.com/LambdaTest/synapse/pkg/core"
+	"github.com/LambdaTest/synapse/pkg/global"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetChangedFilesExplicitBase(t *testing.T) {
+	var requested []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.Path)
+		fmt.Fprint(w, "diff --git a/src/math.js b/src/math.js\n--- a/src/math.js\n+++ b/src/math.js\n@@ -1 +1 @@\n-a\n+b\n")
+	}))
+	defer server.Close()
+
+	apiURL := global.APIHostURLMap[core.GitHub]
+	global.APIHostURLMap[core.GitHub] = server.URL
+	defer func() { global.APIHostURLMap[core.GitHub] = apiURL }()
+
+	tests := []struct {
+		name       string
+		eventType  core.EventType
+		baseCommit string
+		want       string
+	}{
+		{"push uses explicit base", core.EventPush, "implicit", "/org/repo/compare/explicit...target"},
+		{"pull request uses explicit base", core.EventPullRequest, "", "/org/repo/compare/explicit...target"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requested = nil
+			dm := newDiffManager(&config.NucleusConfig{})
+			payload := &core.Payload{
+				GitProvider:       core.GitHub,
+				EventType:         tt.eventType,
+				RepoLink:          "https://github.com/org/repo",
+				PullRequestNumber: 1,
+				BaseCommit:        tt.baseCommit,
+				TargetCommit:      "target",
+				DiffBaseCommit:    "explicit",
+			}
+			got, err := dm.GetChangedFiles(context.TODO(), payload, "")
+			if err != nil {
+				t.Fatalf("GetChangedFiles() error = %v", err)
+			}
+			assert.Equal(t, []string{tt.want}, requested)
+			assert.Equal(t, map[string]int{"src/math.js": core.FileModified}, got)
+		})
+	}
+}
@@ -7,6 +7,18 @@ import (
 // GenericUserFacingBEErrRemark returns a generic error message for user facing errors.
 const GenericUserFacingBEErrRemark = "Unexpected error"
 
+// remarks reported to the user with the status of the task
+const (
+	// CacheDownloadTimeoutErrRemark is the remark of a task whose cache download timed out.
+	CacheDownloadTimeoutErrRemark = "Timed out while downloading cache"
+	// CacheUploadTimeoutErrRemark is the remark of a task whose cache upload timed out.
+	CacheUploadTimeoutErrRemark = "Timed out while uploading cache"
+	// PostRunErrRemark is the remark of a task whose post-run steps failed.
+	PostRunErrRemark = "Error occurred in post-run steps"
+	// CoverageDirErrRemark is the remark of a task whose coverage directory is not writable by the tests.
+	CoverageDirErrRemark = "Coverage directory is not writable"
+)
+
 // Err repersent structure of error
 type Err struct {
 	Code    string
@@ -33,6 +45,21 @@ func New(text string) error {
 	return &Error{Message: text}
 }
 
+// CommandError is returned when a command fails, along with the tail of its output
+type CommandError struct {
+	Err    error
+	Output string
+}
+
+func (e *CommandError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the error of the command
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
+
 // ErrInvalidPayload returns an error when the  nucleus payload is invalid.
 func ErrInvalidPayload(errMsg string) error {
 	return New(errMsg)
@@ -51,6 +78,8 @@ var (
 	ErrSecretRegexMatch = New("secret regex match failed")
 	// ErrNotFound return when azure blob is not found.
 	ErrNotFound = New("blob not found")
+	// ErrBlobChanged is returned when a blob changed since a download was interrupted
+	ErrBlobChanged = New("blob changed since the download started")
 	// ErrSASToken returns when sas token is not found.
 	ErrSASToken = New("azure client requires SAS Token")
 	// ErrAzureCredentials is returned when the azure credentials are invalid.
@@ -61,6 +90,46 @@ var (
 	ErrInvalidLoggerInstance = New("Invalid logger instance")
 	// ErrUnsupportedGitProvider is returned when try to integrate unsupported provider repo
 	ErrUnsupportedGitProvider = New("unsupported gitprovider")
+	// ErrUnsupportedArchiveFormat is returned when the repo archive format is not supported
+	ErrUnsupportedArchiveFormat = New("unsupported archive format")
+	// ErrUnsupportedCloneMethod is returned when the clone method of the repo is not supported
+	ErrUnsupportedCloneMethod = New("unsupported clone method")
+	// ErrUnsupportedPayloadScheme is returned when the scheme of the payload address is not supported
+	ErrUnsupportedPayloadScheme = New("unsupported payload address scheme")
+	// ErrUnsafeArchiveEntry is returned when an archive entry points outside of the extraction directory
+	ErrUnsafeArchiveEntry = New("archive entry escapes the extraction directory")
+	// ErrUnsafeArtifactPath is returned when an artifact path points outside of the repo
+	ErrUnsafeArtifactPath = New("artifact path escapes the repo")
+	// ErrCloneTooLarge is returned when the repo archive exceeds the maximum clone size
+	ErrCloneTooLarge = New("repo archive exceeds the maximum clone size")
+	// ErrPostCloneCheck is returned when a required path is missing in the cloned repo
+	ErrPostCloneCheck = New("required path not found in cloned repo")
 	// ErrGitDiffNotFound is returned when basecommit is null or git provider returns empty diff
 	ErrGitDiffNotFound = New("diff not found")
+	// ErrCacheTimeout is returned when a cache operation does not complete within the configured timeout
+	ErrCacheTimeout = New("cache operation timed out")
+	// ErrCacheExtraction is returned when a downloaded cache can not be extracted after the configured retries
+	ErrCacheExtraction = New("cache extraction failed repeatedly")
+	// ErrCacheCorrupt is returned when an extracted cache does not match the checksums of its manifest
+	ErrCacheCorrupt = New("cache checksum mismatch")
+	// ErrCoverageDirNotWritable is returned when the tests can not write to the coverage directory
+	ErrCoverageDirNotWritable = New("coverage directory is not writable")
+	// ErrInvalidParallelism is returned when the parallelism override of the operator is invalid
+	ErrInvalidParallelism = New("invalid parallelism override")
+	// ErrUnsupportedFramework is returned when the framework override is not a supported framework
+	ErrUnsupportedFramework = New("unsupported framework")
+	// ErrUnknownTier is returned when the configured default tier is not a tier of the configuration file
+	ErrUnknownTier = New("unknown tier")
+	// ErrUndefinedSecret is returned in strict secrets mode when an undefined secret is referenced
+	ErrUndefinedSecret = New("undefined secret referenced")
+	// ErrInvalidBranchName is returned when the branch hook prints a name which is not a legal ref name
+	ErrInvalidBranchName = New("invalid branch name")
+	// ErrDiscoveryWarmup is returned when the warmup command run before the test discovery fails
+	ErrDiscoveryWarmup = New("discovery warmup failed")
+	// ErrInvalidNodeVersion is returned when the node version is not a version or alias understood by nvm
+	ErrInvalidNodeVersion = New("invalid node version")
+	// ErrDiscoveryHook is returned when the discovery hook fails or prints an invalid discovery result
+	ErrDiscoveryHook = New("discovery hook failed")
+	// ErrUnknownTestStatus is returned when a test result has an unknown or empty status
+	ErrUnknownTestStatus = New("unknown test status")
 )
@@ -6,6 +6,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 // CopyFile copies the contents of the file named src to the file named
@@ -147,3 +148,55 @@ func CreateIfNotExists(path string, isDir bool) error {
 
 	return nil
 }
+
+// CheckWritableDir creates the directory if it does not exist and verifies that files can be created in it.
+func CheckWritableDir(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(dir, ".write-check-*")
+	if err != nil {
+		return err
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
+// RemoveWithin removes the given paths relative to root. Paths resolving outside of root,
+// either directly or through symlinked directories, are rejected.
+func RemoveWithin(root string, paths []string) error {
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		target := filepath.Join(root, path)
+		if !IsWithin(root, target) || target == filepath.Clean(root) {
+			return fmt.Errorf("path %s is outside of %s", path, root)
+		}
+		// resolve symlinks in the parent directories, the target itself may be a symlink which is removed as is
+		realParent, err := filepath.EvalSymlinks(filepath.Dir(target))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return err
+		}
+		if !IsWithin(realRoot, filepath.Join(realParent, filepath.Base(target))) {
+			return fmt.Errorf("path %s is outside of %s", path, root)
+		}
+		if err := os.RemoveAll(target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IsWithin checks if path is root or lies inside root
+func IsWithin(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,61 @@
+package fileutils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRemoveWithin(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	for _, path := range []string{"coverage/old.json", ".nyc_output/out.json", "tmp/cache", "src/index.js"} {
+		if err := CreateIfNotExists(filepath.Join(root, path), false); err != nil {
+			t.Fatalf("failed to create file: %v", err)
+		}
+	}
+	if err := CreateIfNotExists(filepath.Join(outside, "keep.txt"), false); err != nil {
+		t.Fatalf("failed to create file: %v", err)
+	}
+	if err := os.Symlink(outside, filepath.Join(root, "linked")); err != nil {
+		t.Fatalf("failed to create symlink: %v", err)
+	}
+
+	err := RemoveWithin(root, []string{"coverage", ".nyc_output/out.json", "tmp/", "missing/file"})
+	assert.Nil(t, err)
+	for path, want := range map[string]bool{
+		"coverage":             false,
+		".nyc_output/out.json": false,
+		".nyc_output":          true,
+		"tmp":                  false,
+		"src/index.js":         true,
+	} {
+		exists, _ := CheckIfExists(filepath.Join(root, path))
+		assert.Equal(t, want, exists, path)
+	}
+
+	for _, path := range []string{"../" + filepath.Base(outside), "src/../..", ".", "linked/keep.txt"} {
+		assert.NotNil(t, RemoveWithin(root, []string{path}), path)
+	}
+	exists, _ := CheckIfExists(filepath.Join(outside, "keep.txt"))
+	assert.True(t, exists)
+	exists, _ = CheckIfExists(filepath.Join(root, "src/index.js"))
+	assert.True(t, exists)
+}
+
+func TestCheckWritableDir(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "scratch", "tas")
+	assert.Nil(t, CheckWritableDir(dir))
+	entries, err := os.ReadDir(dir)
+	assert.Nil(t, err)
+	assert.Empty(t, entries)
+
+	file := filepath.Join(root, "file")
+	if err := CreateIfNotExists(file, false); err != nil {
+		t.Fatalf("failed to create file: %v", err)
+	}
+	assert.NotNil(t, CheckWritableDir(filepath.Join(file, "tas")))
+}
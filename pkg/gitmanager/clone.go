@@ -0,0 +1,75 @@
+package gitmanager
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+
+	"github.com/LambdaTest/synapse/pkg/core"
+)
+
+// cloneGit fetches the target commit with git and checks it out in the repo dir,
+// the history is fetched up to the configured depth.
+func (gm *gitManager) cloneGit(ctx context.Context, payload *core.Payload, cloneToken string) error {
+	if err := os.MkdirAll(gm.repoDir, os.ModePerm); err != nil {
+		gm.logger.Errorf("failed to create dir %s, error: %v", gm.repoDir, err)
+		return err
+	}
+	fetch := []string{"fetch", "--quiet", "--no-tags"}
+	if gm.cloneDepth > 0 {
+		fetch = append(fetch, "--depth", strconv.Itoa(gm.cloneDepth))
+	}
+	fetch = append(fetch, "origin", payload.TargetCommit)
+	steps := [][]string{
+		{"init", "--quiet"},
+		{"remote", "add", "origin", payload.RepoLink},
+		fetch,
+		{"checkout", "--quiet", "--detach", "FETCH_HEAD"},
+	}
+	gm.logger.Debugf("cloning %s with git", payload.RepoLink)
+	env := gitAuthEnv(payload.GitProvider, cloneToken)
+	for _, args := range steps {
+		if err := gm.runGit(ctx, env, args...); err != nil {
+			gm.logger.Errorf("failed to clone with git, error %v", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// runGit runs git in the repo dir, the output is included in the error
+func (gm *gitManager) runGit(ctx context.Context, env []string, args ...string) error {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = gm.repoDir
+	cmd.Env = append(os.Environ(), env...)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("git %s: %w: %s", args[0], err, bytes.TrimSpace(out.Bytes()))
+	}
+	return nil
+}
+
+// gitAuthEnv returns the environment passing the clone token to git. The token is set through
+// the environment so that it is neither in the arguments nor in the config of the cloned repo.
+func gitAuthEnv(gitProvider, cloneToken string) []string {
+	env := []string{"GIT_TERMINAL_PROMPT=0"}
+	if cloneToken == "" {
+		return env
+	}
+	user := "x-access-token"
+	if gitProvider == core.GitLab {
+		user = "oauth2"
+	}
+	auth := base64.StdEncoding.EncodeToString([]byte(user + ":" + cloneToken))
+	return append(env,
+		"GIT_CONFIG_COUNT=1",
+		"GIT_CONFIG_KEY_0=http.extraHeader",
+		"GIT_CONFIG_VALUE_0=Authorization: Basic "+auth,
+	)
+}
@@ -0,0 +1,126 @@
+package gitmanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// minRangePartSize is the minimum size of each part when downloading in ranges,
+// smaller files are downloaded in a single stream.
+var minRangePartSize int64 = 4 << 20
+
+var errRangeNotSupported = errors.New("range requests not supported")
+
+// newRequest creates an http request authorized with the clone token
+func newRequest(ctx context.Context, method, url, cloneToken string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if cloneToken != "" {
+		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", cloneToken))
+	}
+	return req, nil
+}
+
+// rangeSupportedSize returns the size of the file at url if the server supports range requests
+func (gm *gitManager) rangeSupportedSize(ctx context.Context, url, cloneToken string) (int64, error) {
+	req, err := newRequest(ctx, http.MethodHead, url, cloneToken)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := gm.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Accept-Ranges") != "bytes" || resp.ContentLength <= 0 {
+		return 0, errRangeNotSupported
+	}
+	return resp.ContentLength, nil
+}
+
+// numRangeParts returns the number of parts in which a file of given size is downloaded
+func numRangeParts(size int64, concurrency int) int {
+	parts := int(size / minRangePartSize)
+	if parts > concurrency {
+		parts = concurrency
+	}
+	return parts
+}
+
+// downloadRanges downloads the file at url using concurrent range requests,
+// each part is written at its offset in the output file.
+func (gm *gitManager) downloadRanges(ctx context.Context, url, path, cloneToken string, size int64, parts int) error {
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	if err := out.Truncate(size); err != nil {
+		return err
+	}
+
+	gm.logger.Debugf("downloading %s in %d parts", url, parts)
+	partSize := size / int64(parts)
+	g, ctx := errgroup.WithContext(ctx)
+	for i := 0; i < parts; i++ {
+		start := int64(i) * partSize
+		end := start + partSize - 1
+		if i == parts-1 {
+			end = size - 1
+		}
+		g.Go(func() error {
+			return gm.downloadRange(ctx, url, cloneToken, out, start, end)
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+// downloadRange downloads the bytes from start to end (inclusive) and writes them at the same offset in out
+func (gm *gitManager) downloadRange(ctx context.Context, url, cloneToken string, out io.WriterAt, start, end int64) error {
+	req, err := newRequest(ctx, http.MethodGet, url, cloneToken)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", start, end))
+	resp, err := gm.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusPartialContent {
+		return fmt.Errorf("unexpected status %d for range %d-%d", resp.StatusCode, start, end)
+	}
+	written, err := io.Copy(&offsetWriter{w: out, offset: start}, io.LimitReader(resp.Body, end-start+1))
+	if err != nil {
+		return err
+	}
+	if written != end-start+1 {
+		return fmt.Errorf("short read for range %d-%d, got %d bytes", start, end, written)
+	}
+	return nil
+}
+
+// offsetWriter writes sequentially to an io.WriterAt starting at offset
+type offsetWriter struct {
+	w      io.WriterAt
+	offset int64
+}
+
+func (o *offsetWriter) Write(p []byte) (int, error) {
+	n, err := o.w.WriteAt(p, o.offset)
+	o.offset += int64(n)
+	return n, err
+}
@@ -0,0 +1,118 @@
+package gitmanager
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"log"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/LambdaTest/synapse/pkg/errs"
+	"github.com/LambdaTest/synapse/pkg/lumber"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDownloadFileRanges(t *testing.T) {
+	logger, err := lumber.NewLogger(lumber.LoggingConfig{EnableConsole: true}, true, lumber.InstanceZapLogger)
+	if err != nil {
+		log.Fatalf("Could not instantiate logger %s", err.Error())
+	}
+	defer func(size int64) { minRangePartSize = size }(minRangePartSize)
+	minRangePartSize = 1 << 10
+
+	content := make([]byte, 10<<10+123)
+	rand.New(rand.NewSource(1)).Read(content) // nolint:errcheck
+
+	tests := []struct {
+		name         string
+		acceptRanges bool
+		wantRanges   int32
+	}{
+		{"range requests", true, 4},
+		{"no range support", false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var rangeRequests int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if !tt.acceptRanges {
+					w.Write(content) // nolint:errcheck
+					return
+				}
+				if r.Header.Get("Range") != "" {
+					atomic.AddInt32(&rangeRequests, 1)
+				}
+				http.ServeContent(w, r, "archive", time.Time{}, bytes.NewReader(content))
+			}))
+			defer server.Close()
+
+			gm := &gitManager{logger: logger, downloadConcurrency: 4}
+			path := filepath.Join(t.TempDir(), "archive.bin")
+			if err := gm.downloadFile(context.TODO(), server.URL, path, filepath.Dir(path), ""); err != nil {
+				t.Fatalf("downloadFile() error = %v", err)
+			}
+			got, err := ioutil.ReadFile(path)
+			if err != nil {
+				t.Fatalf("failed to read downloaded file: %v", err)
+			}
+			assert.True(t, bytes.Equal(content, got), "downloaded file differs from source")
+			assert.Equal(t, tt.wantRanges, atomic.LoadInt32(&rangeRequests))
+		})
+	}
+}
+
+func TestDownloadFileMaxCloneSize(t *testing.T) {
+	logger, err := lumber.NewLogger(lumber.LoggingConfig{EnableConsole: true}, true, lumber.InstanceZapLogger)
+	if err != nil {
+		log.Fatalf("Could not instantiate logger %s", err.Error())
+	}
+	content := bytes.Repeat([]byte("x"), 2048)
+
+	tests := []struct {
+		name          string
+		contentLength bool
+		concurrency   int
+		maxCloneSize  int64
+		wantErr       bool
+	}{
+		{"within limit", true, 1, 4096, false},
+		{"content length exceeds limit", true, 1, 1024, true},
+		{"stream exceeds limit", false, 1, 1024, true},
+		{"range size exceeds limit", true, 4, 1024, true},
+		{"no limit", false, 1, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.contentLength {
+					http.ServeContent(w, r, "archive", time.Time{}, bytes.NewReader(content))
+					return
+				}
+				// flushing forces a chunked response without content length
+				w.Write(content[:1]) // nolint:errcheck
+				w.(http.Flusher).Flush()
+				w.Write(content[1:]) // nolint:errcheck
+			}))
+			defer server.Close()
+
+			gm := &gitManager{logger: logger, downloadConcurrency: tt.concurrency, maxCloneSize: tt.maxCloneSize}
+			path := filepath.Join(t.TempDir(), "archive.bin")
+			err := gm.downloadFile(context.TODO(), server.URL, path, filepath.Dir(path), "")
+			if !tt.wantErr {
+				assert.Nil(t, err)
+				return
+			}
+			assert.True(t, errors.Is(err, errs.ErrCloneTooLarge), "unexpected error %v", err)
+			_, statErr := os.Stat(path)
+			assert.True(t, os.IsNotExist(statErr), "partial archive is left behind")
+		})
+	}
+}
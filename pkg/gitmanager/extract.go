@@ -0,0 +1,276 @@
+package gitmanager
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/LambdaTest/synapse/pkg/errs"
+	"github.com/LambdaTest/synapse/pkg/fileutils"
+	"github.com/LambdaTest/synapse/pkg/global"
+	"github.com/klauspost/compress/zip"
+	"github.com/mholt/archiver/v3"
+)
+
+// symlinkEntry is a symlink of the archive, created once all the other entries are extracted
+type symlinkEntry struct {
+	name   string
+	target string
+}
+
+// extractArchive extracts the archive into dest. Entries resolving outside of dest are rejected.
+// Symlinks are created last, so that no entry is written through a symlink of the archive.
+// Symlinks escaping the top level directory of the archive are rejected as well, the others
+// are preserved or materialized based on the symlink mode.
+func (gm *gitManager) extractArchive(walker archiver.Walker, archive, dest string) error {
+	switch gm.symlinkMode {
+	case global.SymlinkModePreserve, global.SymlinkModeMaterialize, "":
+	default:
+		return fmt.Errorf("invalid symlink mode %q", gm.symlinkMode)
+	}
+
+	realDest, err := resolveSymlinks(dest)
+	if err != nil {
+		return err
+	}
+	var links []symlinkEntry
+	extractEntry := func(f archiver.File) error {
+		var name string
+		switch header := f.Header.(type) {
+		case zip.FileHeader:
+			name = header.Name
+			if f.Mode()&os.ModeSymlink != 0 {
+				if _, err := entryPath(dest, realDest, name); err != nil {
+					return err
+				}
+				target, err := ioutil.ReadAll(f)
+				if err != nil {
+					return fmt.Errorf("%s: reading symlink target: %v", name, err)
+				}
+				links = append(links, symlinkEntry{name: name, target: strings.TrimSpace(string(target))})
+				return nil
+			}
+		case *tar.Header:
+			name = header.Name
+			switch header.Typeflag {
+			case tar.TypeSymlink:
+				if _, err := entryPath(dest, realDest, name); err != nil {
+					return err
+				}
+				links = append(links, symlinkEntry{name: name, target: header.Linkname})
+				return nil
+			case tar.TypeDir, tar.TypeReg, tar.TypeRegA:
+			case tar.TypeXGlobalHeader:
+				// pax global header of git generated tarballs
+				return nil
+			default:
+				gm.logger.Debugf("skipping archive entry %s of type %c", name, header.Typeflag)
+				return nil
+			}
+		default:
+			return fmt.Errorf("unexpected archive header %T", f.Header)
+		}
+
+		path, err := entryPath(dest, realDest, name)
+		if err != nil {
+			return err
+		}
+		if f.IsDir() {
+			return os.MkdirAll(path, f.Mode().Perm()|0700)
+		}
+		return writeFile(path, f, f.Mode().Perm())
+	}
+	// the walker does not wrap the errors, keep the original one for the callers
+	var entryErr error
+	err = walker.Walk(archive, func(f archiver.File) error {
+		entryErr = extractEntry(f)
+		return entryErr
+	})
+	if entryErr != nil {
+		return entryErr
+	}
+	if err != nil {
+		return err
+	}
+	return gm.createSymlinks(dest, realDest, links)
+}
+
+// createSymlinks creates the symlinks of the archive after validating that they resolve within
+// the top level directory of their entry.
+func (gm *gitManager) createSymlinks(dest, realDest string, links []symlinkEntry) error {
+	for _, link := range links {
+		// the parent directories may be symlinks created in this loop
+		path, err := entryPath(dest, realDest, link.name)
+		if err != nil {
+			return err
+		}
+		target := link.target
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(path), target)
+		}
+		if !fileutils.IsWithin(entryRoot(dest, link.name), target) {
+			return fmt.Errorf("%w: symlink %s -> %s", errs.ErrUnsafeArchiveEntry, link.name, link.target)
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := os.RemoveAll(path); err != nil {
+			return err
+		}
+		if err := os.Symlink(link.target, path); err != nil {
+			return err
+		}
+	}
+
+	// symlinks pointing to other symlinks can still escape once resolved
+	resolved := make([]string, len(links))
+	for i, link := range links {
+		root, err := filepath.EvalSymlinks(entryRoot(dest, link.name))
+		if err != nil {
+			return err
+		}
+		target, err := resolveSymlinks(filepath.Join(dest, link.name))
+		if err != nil {
+			gm.logger.Warnf("failed to resolve symlink %s -> %s in archive: %v", link.name, link.target, err)
+			continue
+		}
+		if !fileutils.IsWithin(root, target) {
+			return fmt.Errorf("%w: symlink %s -> %s", errs.ErrUnsafeArchiveEntry, link.name, link.target)
+		}
+		if _, err := os.Stat(target); err != nil {
+			gm.logger.Warnf("dangling symlink %s -> %s in archive", link.name, link.target)
+			continue
+		}
+		resolved[i] = target
+	}
+
+	if gm.symlinkMode != global.SymlinkModeMaterialize {
+		return nil
+	}
+	for i, link := range links {
+		if err := gm.materializeSymlink(filepath.Join(dest, link.name), resolved[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// materializeSymlink replaces the symlink at path by a copy of the file it resolves to.
+// Dangling symlinks are removed and symlinks to directories are kept as is.
+func (gm *gitManager) materializeSymlink(path, target string) error {
+	if target == "" {
+		return os.Remove(path)
+	}
+	info, err := os.Stat(target)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		gm.logger.Debugf("keeping symlink %s to directory %s", path, target)
+		return nil
+	}
+	src, err := os.Open(target)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	if err := os.Remove(path); err != nil {
+		return err
+	}
+	return writeFile(path, src, info.Mode().Perm())
+}
+
+// entryPath returns the path the archive entry is extracted to. Absolute names and names
+// resolving outside of dest, either through ".." or through symlinked directories, are rejected.
+func entryPath(dest, realDest, name string) (string, error) {
+	path := filepath.Join(dest, name)
+	if filepath.IsAbs(name) || !fileutils.IsWithin(dest, path) {
+		return "", fmt.Errorf("%w: %s", errs.ErrUnsafeArchiveEntry, name)
+	}
+	realParent, err := resolveSymlinks(filepath.Dir(path))
+	if err != nil {
+		return "", err
+	}
+	if !fileutils.IsWithin(realDest, filepath.Join(realParent, filepath.Base(path))) {
+		return "", fmt.Errorf("%w: %s", errs.ErrUnsafeArchiveEntry, name)
+	}
+	return path, nil
+}
+
+// maxSymlinkHops is the number of symlinks followed before giving up on resolving a path
+const maxSymlinkHops = 40
+
+// resolveSymlinks returns the absolute path with all the symlinks resolved. Unlike filepath.EvalSymlinks
+// it also resolves dangling symlinks, the components that do not exist are joined as is.
+func resolveSymlinks(path string) (string, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	resolved := string(filepath.Separator)
+	pending := strings.Split(strings.TrimPrefix(path, resolved), string(filepath.Separator))
+	for hops := 0; len(pending) > 0; {
+		component := pending[0]
+		pending = pending[1:]
+		if component == "" || component == "." {
+			continue
+		}
+		if component == ".." {
+			resolved = filepath.Dir(resolved)
+			continue
+		}
+		next := filepath.Join(resolved, component)
+		info, err := os.Lstat(next)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return filepath.Join(append([]string{next}, pending...)...), nil
+			}
+			return "", err
+		}
+		if info.Mode()&os.ModeSymlink == 0 {
+			resolved = next
+			continue
+		}
+		if hops++; hops > maxSymlinkHops {
+			return "", fmt.Errorf("too many links resolving %s", path)
+		}
+		target, err := os.Readlink(next)
+		if err != nil {
+			return "", err
+		}
+		if filepath.IsAbs(target) {
+			resolved = string(filepath.Separator)
+		}
+		pending = append(strings.Split(target, string(filepath.Separator)), pending...)
+	}
+	return resolved, nil
+}
+
+// entryRoot returns the top level directory of the archive entry, the repo is archived under it.
+func entryRoot(dest, name string) string {
+	name = strings.TrimPrefix(filepath.ToSlash(filepath.Clean(name)), "/")
+	if idx := strings.Index(name, "/"); idx != -1 {
+		return filepath.Join(dest, name[:idx])
+	}
+	return filepath.Clean(dest)
+}
+
+// writeFile writes the content of r to path, replacing the file if it exists.
+func writeFile(path string, r io.Reader, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	out, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, r); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
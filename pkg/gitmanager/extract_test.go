@@ -0,0 +1,196 @@
+package gitmanager
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/LambdaTest/synapse/pkg/errs"
+	"github.com/LambdaTest/synapse/pkg/global"
+	"github.com/LambdaTest/synapse/pkg/lumber"
+	"github.com/stretchr/testify/assert"
+)
+
+// archiveEntry is a file, or a symlink if target is set, of a crafted archive
+type archiveEntry struct {
+	name    string
+	content string
+	target  string
+}
+
+// writeArchive writes the entries to a zip or tar.gz archive at path
+func writeArchive(t *testing.T, path, format string, entries []archiveEntry) {
+	out, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("failed to create archive: %v", err)
+	}
+	defer out.Close()
+
+	if format == global.ArchiveFormatZip {
+		zw := zip.NewWriter(out)
+		for _, e := range entries {
+			header := &zip.FileHeader{Name: e.name, Method: zip.Deflate}
+			content := e.content
+			header.SetMode(0644)
+			if e.target != "" {
+				header.SetMode(0777 | os.ModeSymlink)
+				content = e.target
+			}
+			w, err := zw.CreateHeader(header)
+			if err != nil {
+				t.Fatalf("failed to create zip entry: %v", err)
+			}
+			if _, err := io.WriteString(w, content); err != nil {
+				t.Fatalf("failed to write zip entry: %v", err)
+			}
+		}
+		if err := zw.Close(); err != nil {
+			t.Fatalf("failed to close zip: %v", err)
+		}
+		return
+	}
+
+	gw := gzip.NewWriter(out)
+	tw := tar.NewWriter(gw)
+	for _, e := range entries {
+		header := &tar.Header{Name: e.name, Mode: 0644, Typeflag: tar.TypeReg, Size: int64(len(e.content))}
+		if e.target != "" {
+			header = &tar.Header{Name: e.name, Mode: 0777, Typeflag: tar.TypeSymlink, Linkname: e.target}
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatalf("failed to write tar header: %v", err)
+		}
+		if e.target == "" {
+			if _, err := io.WriteString(tw, e.content); err != nil {
+				t.Fatalf("failed to write tar entry: %v", err)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("failed to close tar: %v", err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatalf("failed to close gzip: %v", err)
+	}
+}
+
+func TestExtractFileSymlinks(t *testing.T) {
+	logger, err := lumber.NewLogger(lumber.LoggingConfig{EnableConsole: true}, true, lumber.InstanceZapLogger)
+	if err != nil {
+		log.Fatalf("Could not instantiate logger %s", err.Error())
+	}
+	readme := archiveEntry{name: "repo/README.md", content: "readme"}
+
+	tests := []struct {
+		name    string
+		entries []archiveEntry
+		wantErr error
+	}{
+		{"in repo symlink", []archiveEntry{readme, {name: "repo/docs/README.md", target: "../README.md"}}, nil},
+		{"dangling symlink", []archiveEntry{readme, {name: "repo/docs/missing.md", target: "../missing.md"}}, nil},
+		{"escaping symlink", []archiveEntry{readme, {name: "repo/passwd", target: "../../../etc/passwd"}}, errs.ErrUnsafeArchiveEntry},
+		{"absolute symlink", []archiveEntry{readme, {name: "repo/passwd", target: "/etc/passwd"}}, errs.ErrUnsafeArchiveEntry},
+		{"symlink to sibling dir", []archiveEntry{readme, {name: "repo/other", target: "../other"}}, errs.ErrUnsafeArchiveEntry},
+		{"escaping through symlinked dir", []archiveEntry{readme, {name: "repo/self", target: "."}, {name: "repo/self/up", target: "../outside"}}, errs.ErrUnsafeArchiveEntry},
+	}
+	for _, format := range []string{global.ArchiveFormatZip, global.ArchiveFormatTarGz} {
+		for _, mode := range []string{global.SymlinkModePreserve, global.SymlinkModeMaterialize} {
+			for _, tt := range tests {
+				t.Run(format+"/"+mode+"/"+tt.name, func(t *testing.T) {
+					gm := &gitManager{logger: logger, symlinkMode: mode}
+					dir := t.TempDir()
+					path := filepath.Join(dir, commitID+"."+format)
+					writeArchive(t, path, format, tt.entries)
+
+					err := gm.extractFile(path, dir)
+					if tt.wantErr != nil {
+						if !errors.Is(err, tt.wantErr) {
+							t.Errorf("extractFile() error = %v, want %v", err, tt.wantErr)
+						}
+						return
+					}
+					if err != nil {
+						t.Fatalf("extractFile() error = %v", err)
+					}
+
+					for _, e := range tt.entries[1:] {
+						info, err := os.Lstat(filepath.Join(dir, e.name))
+						dangling := e.target == "../missing.md"
+						switch {
+						case mode == global.SymlinkModeMaterialize && dangling:
+							assert.True(t, os.IsNotExist(err))
+						case mode == global.SymlinkModeMaterialize:
+							assert.Nil(t, err)
+							assert.True(t, info.Mode().IsRegular())
+							content, _ := ioutil.ReadFile(filepath.Join(dir, e.name))
+							assert.Equal(t, "readme", string(content))
+						default:
+							assert.Nil(t, err)
+							target, _ := os.Readlink(filepath.Join(dir, e.name))
+							assert.Equal(t, e.target, target)
+						}
+					}
+				})
+			}
+		}
+	}
+}
+
+func TestExtractFileZipSlip(t *testing.T) {
+	logger, err := lumber.NewLogger(lumber.LoggingConfig{EnableConsole: true}, true, lumber.InstanceZapLogger)
+	if err != nil {
+		log.Fatalf("Could not instantiate logger %s", err.Error())
+	}
+	gm := &gitManager{logger: logger}
+
+	tests := []struct {
+		name    string
+		entries []archiveEntry
+		// outside is the name of the file which must not be created next to the extraction dir
+		outside string
+		// symlinkedRepo creates the repo dir in the extraction dir as a symlink to a dir outside of it
+		symlinkedRepo bool
+	}{
+		{"parent dir entry", []archiveEntry{{name: "repo/README.md"}, {name: "../../evil", content: "evil"}}, "evil", false},
+		{"nested parent dir entry", []archiveEntry{{name: "repo/../../evil", content: "evil"}}, "evil", false},
+		{"absolute entry", []archiveEntry{{name: "/evil", content: "evil"}}, "", false},
+		{"parent dir symlink entry", []archiveEntry{{name: "../evil", target: "repo"}}, "evil", false},
+		{"entry through symlinked dir", []archiveEntry{{name: "repo/evil", content: "evil"}}, "evil", true},
+	}
+	for _, format := range []string{global.ArchiveFormatZip, global.ArchiveFormatTarGz} {
+		for _, tt := range tests {
+			t.Run(format+"/"+tt.name, func(t *testing.T) {
+				root := t.TempDir()
+				dest := filepath.Join(root, "a", "b")
+				if err := os.MkdirAll(dest, 0755); err != nil {
+					t.Fatalf("failed to create dir: %v", err)
+				}
+				if tt.symlinkedRepo {
+					if err := os.Symlink(root, filepath.Join(dest, "repo")); err != nil {
+						t.Fatalf("failed to create symlink: %v", err)
+					}
+				}
+				path := filepath.Join(dest, commitID+"."+format)
+				writeArchive(t, path, format, tt.entries)
+
+				err := gm.extractFile(path, dest)
+				if !errors.Is(err, errs.ErrUnsafeArchiveEntry) {
+					t.Errorf("extractFile() error = %v, want %v", err, errs.ErrUnsafeArchiveEntry)
+				}
+				if tt.outside != "" {
+					for _, dir := range []string{root, filepath.Join(root, "a")} {
+						_, err := os.Lstat(filepath.Join(dir, tt.outside))
+						assert.True(t, os.IsNotExist(err), "%s written outside of the extraction dir", tt.outside)
+					}
+				}
+			})
+		}
+	}
+}
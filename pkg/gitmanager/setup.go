@@ -2,6 +2,7 @@
 package gitmanager
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
@@ -10,6 +11,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/LambdaTest/synapse/config"
 	"github.com/LambdaTest/synapse/pkg/core"
 	"github.com/LambdaTest/synapse/pkg/errs"
 	"github.com/LambdaTest/synapse/pkg/global"
@@ -18,40 +20,101 @@ import (
 	"github.com/mholt/archiver/v3"
 )
 
+var (
+	zipMagic  = []byte("PK\x03\x04")
+	gzipMagic = []byte{0x1f, 0x8b}
+)
+
 type gitManager struct {
-	logger     lumber.Logger
-	httpClient http.Client
+	logger              lumber.Logger
+	httpClient          http.Client
+	archiveFormat       string
+	postCloneChecks     []string
+	downloadConcurrency int
+	symlinkMode         string
+	tempDir             string
+	repoDir             string
+	// cloneMethod is either an archive download or a git clone of the target commit
+	cloneMethod string
+	// cloneDepth is the number of commits fetched by a git clone, 0 fetches the full history
+	cloneDepth int
+	// maxCloneSize is the maximum size in bytes of the downloaded archive, 0 disables the limit
+	maxCloneSize int64
 }
 
 // NewGitManager returns a new GitManager
-func NewGitManager(logger lumber.Logger) core.GitManager {
-	return &gitManager{logger: logger, httpClient: http.Client{
-		Timeout: global.DefaultHTTPTimeout,
-	}}
+func NewGitManager(cfg *config.NucleusConfig, logger lumber.Logger) core.GitManager {
+	archiveFormat := cfg.CloneArchiveFormat
+	if archiveFormat == "" {
+		archiveFormat = global.ArchiveFormatZip
+	}
+	cloneMethod := cfg.CloneMethod
+	if cloneMethod == "" {
+		cloneMethod = global.CloneMethodArchive
+	}
+	return &gitManager{
+		logger:              logger,
+		archiveFormat:       archiveFormat,
+		postCloneChecks:     cfg.PostCloneChecks,
+		downloadConcurrency: cfg.CloneDownloadConcurrency,
+		symlinkMode:         cfg.CloneSymlinkMode,
+		tempDir:             cfg.TempDir,
+		repoDir:             global.RepoDir,
+		cloneMethod:         cloneMethod,
+		cloneDepth:          cfg.CloneDepth,
+		maxCloneSize:        int64(cfg.MaxCloneSize) * global.MB,
+		httpClient: http.Client{
+			Timeout: global.DefaultHTTPTimeout,
+		}}
 }
 
 func (gm *gitManager) Clone(ctx context.Context, payload *core.Payload, cloneToken string) error {
+	var err error
+	switch gm.cloneMethod {
+	case global.CloneMethodArchive:
+		err = gm.cloneArchive(ctx, payload, cloneToken)
+	case global.CloneMethodGit:
+		err = gm.cloneGit(ctx, payload, cloneToken)
+	default:
+		err = fmt.Errorf("%w: %s", errs.ErrUnsupportedCloneMethod, gm.cloneMethod)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err = checkRequiredPaths(gm.repoDir, gm.postCloneChecks); err != nil {
+		gm.logger.Errorf("post clone checks failed, error %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// cloneArchive downloads the archive of the target commit and extracts it as the repo dir
+func (gm *gitManager) cloneArchive(ctx context.Context, payload *core.Payload, cloneToken string) error {
 	repoLink := payload.RepoLink
 	repoItems := strings.Split(repoLink, "/")
 	repoName := repoItems[len(repoItems)-1]
 	commitID := payload.TargetCommit
-	archiveURL, err := urlmanager.GetCloneURL(payload.GitProvider, repoLink, repoName, commitID)
+	archiveURL, err := urlmanager.GetCloneURL(payload.GitProvider, repoLink, repoName, commitID, gm.archiveFormat)
 	if err != nil {
 		gm.logger.Errorf("failed to get clone url for provider %s, error %v", payload.GitProvider, err)
 		return err
 	}
 	gm.logger.Debugf("cloning from %s", archiveURL)
-	err = gm.downloadFile(ctx, archiveURL, commitID+".zip", cloneToken)
+	// the archive is extracted next to the repo dir, so that it is moved within the same filesystem
+	archivePath := filepath.Join(gm.tempDir, commitID+"."+gm.archiveFormat)
+	defer os.Remove(archivePath)
+	err = gm.downloadFile(ctx, archiveURL, archivePath, filepath.Dir(gm.repoDir), cloneToken)
 	if err != nil {
 		gm.logger.Errorf("failed to download file %v", err)
 		return err
 	}
 
-	if err = os.Rename(repoName+"-"+commitID, global.RepoDir); err != nil {
+	if err = os.Rename(filepath.Join(filepath.Dir(gm.repoDir), repoName+"-"+commitID), gm.repoDir); err != nil {
 		gm.logger.Errorf("failed to rename dir, error %v", err)
 		return err
 	}
-
 	return nil
 }
 
@@ -68,7 +131,7 @@ func (gm *gitManager) CloneYML(ctx context.Context, payload *core.Payload, clone
 		return err
 	}
 	tasConfigFilePath := commitID + payload.TasFileName
-	if err := gm.downloadFile(ctx, archiveURL, commitID+payload.TasFileName, cloneToken); err != nil {
+	if err := gm.downloadFile(ctx, archiveURL, commitID+payload.TasFileName, "", cloneToken); err != nil {
 		gm.logger.Errorf("error while cloning yaml for commitID %s, error: %v", commitID, err)
 		return err
 	}
@@ -80,15 +143,28 @@ func (gm *gitManager) CloneYML(ctx context.Context, payload *core.Payload, clone
 	return nil
 }
 
-// downloadFile clones the archive from github and extracts the file if it is a zip file.
-func (gm *gitManager) downloadFile(ctx context.Context, archiveURL, fileName, cloneToken string) error {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, archiveURL, nil)
+// downloadFile clones the archive from github and extracts the file into dest if it is an archive.
+func (gm *gitManager) downloadFile(ctx context.Context, archiveURL, fileName, dest, cloneToken string) error {
+	if gm.downloadConcurrency > 1 {
+		size, err := gm.rangeSupportedSize(ctx, archiveURL, cloneToken)
+		if err != nil {
+			gm.logger.Debugf("falling back to single stream download for %s: %v", archiveURL, err)
+		} else if err := gm.checkCloneSize(size); err != nil {
+			gm.logger.Errorf("refusing to download %s, error %v", archiveURL, err)
+			return err
+		} else if parts := numRangeParts(size, gm.downloadConcurrency); parts > 1 {
+			if err := gm.downloadRanges(ctx, archiveURL, fileName, cloneToken, size, parts); err != nil {
+				gm.logger.Errorf("failed to download file in ranges %v", err)
+				return err
+			}
+			return gm.extractFile(fileName, dest)
+		}
+	}
+
+	req, err := newRequest(ctx, http.MethodGet, archiveURL, cloneToken)
 	if err != nil {
 		return err
 	}
-	if cloneToken != "" {
-		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", cloneToken))
-	}
 	resp, err := gm.httpClient.Do(req)
 	if err != nil {
 		gm.logger.Errorf("error while making http request %v", err)
@@ -100,7 +176,12 @@ func (gm *gitManager) downloadFile(ctx context.Context, archiveURL, fileName, cl
 		gm.logger.Errorf("non 200 status while cloning from endpoint %s, status %d ", archiveURL, resp.StatusCode)
 		return errs.ErrApiStatus
 	}
-	err = gm.copyAndExtractFile(resp, fileName)
+	// the size is enforced while copying as well, the content length may be absent
+	if err := gm.checkCloneSize(resp.ContentLength); err != nil {
+		gm.logger.Errorf("refusing to download %s, error %v", archiveURL, err)
+		return err
+	}
+	err = gm.copyAndExtractFile(resp, fileName, dest)
 	if err != nil {
 		gm.logger.Errorf("failed to copy file %v", err)
 		return err
@@ -109,29 +190,96 @@ func (gm *gitManager) downloadFile(ctx context.Context, archiveURL, fileName, cl
 }
 
 // copyAndExtractFile copies the content of http response directly to the local storage
-// and extracts the file if it is a zip file.
-func (gm *gitManager) copyAndExtractFile(resp *http.Response, path string) error {
+// and extracts the file into dest if it is a zip or tar.gz archive.
+func (gm *gitManager) copyAndExtractFile(resp *http.Response, path, dest string) error {
 	out, err := os.Create(path)
 	if err != nil {
 		return err
 	}
-	_, err = io.Copy(out, resp.Body)
+	var body io.Reader = resp.Body
+	if gm.maxCloneSize > 0 {
+		body = io.LimitReader(resp.Body, gm.maxCloneSize+1)
+	}
+	written, err := io.Copy(out, body)
+	if err == nil {
+		err = gm.checkCloneSize(written)
+	}
 	if err != nil {
 		gm.logger.Errorf("failed to copy file %v", err)
 		out.Close()
+		os.Remove(path)
 		return err
 	}
 	out.Close()
 
-	// if zip file, then unarchive the file in same path
-	if filepath.Ext(path) == ".zip" {
-		zip := archiver.NewZip()
-		zip.OverwriteExisting = true
-		if err := zip.Unarchive(path, filepath.Dir(path)); err != nil {
-			gm.logger.Errorf("failed to unarchive file %v", err)
-			return err
+	return gm.extractFile(path, dest)
+}
+
+// extractFile unarchives the file into dest if it is a zip or tar.gz archive.
+func (gm *gitManager) extractFile(path, dest string) error {
+	if !isArchive(path) {
+		return nil
+	}
+	walker, err := newArchiveWalker(path)
+	if err != nil {
+		gm.logger.Errorf("failed to detect archive type %v", err)
+		return err
+	}
+	if err := gm.extractArchive(walker, path, dest); err != nil {
+		gm.logger.Errorf("failed to unarchive file %v", err)
+		return err
+	}
+	return nil
+}
+
+// checkCloneSize verifies that size does not exceed the maximum clone size
+func (gm *gitManager) checkCloneSize(size int64) error {
+	if gm.maxCloneSize > 0 && size > gm.maxCloneSize {
+		return fmt.Errorf("%w: more than %d bytes", errs.ErrCloneTooLarge, gm.maxCloneSize)
+	}
+	return nil
+}
 
+// checkRequiredPaths verifies that each of the paths exists in repoDir
+func checkRequiredPaths(repoDir string, paths []string) error {
+	for _, path := range paths {
+		if _, err := os.Stat(filepath.Join(repoDir, path)); err != nil {
+			if os.IsNotExist(err) {
+				return fmt.Errorf("%w: %s", errs.ErrPostCloneCheck, path)
+			}
+			return err
 		}
 	}
-	return err
+	return nil
+}
+
+// isArchive checks if the file at path is an archive based on its extension
+func isArchive(path string) bool {
+	return strings.HasSuffix(path, ".zip") || strings.HasSuffix(path, ".tar.gz") || strings.HasSuffix(path, ".tgz")
+}
+
+// newArchiveWalker returns the walker for the archive at path. The type is detected
+// from the content of the file, as some endpoints do not honour the requested format.
+func newArchiveWalker(path string) (archiver.Walker, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	header := make([]byte, len(zipMagic))
+	n, err := io.ReadFull(f, header)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	header = header[:n]
+
+	switch {
+	case bytes.HasPrefix(header, zipMagic):
+		return archiver.NewZip(), nil
+	case bytes.HasPrefix(header, gzipMagic):
+		return archiver.NewTarGz(), nil
+	default:
+		return nil, errs.ErrUnsupportedArchiveFormat
+	}
 }
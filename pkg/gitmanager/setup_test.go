@@ -0,0 +1,238 @@
+package gitmanager
+
+import (
+	"context"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/LambdaTest/synapse/pkg/core"
+	"github.com/LambdaTest/synapse/pkg/errs"
+	"github.com/LambdaTest/synapse/pkg/global"
+	"github.com/LambdaTest/synapse/pkg/lumber"
+	"github.com/mholt/archiver/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+const commitID = "abc123"
+
+func TestCopyAndExtractFile(t *testing.T) {
+	logger, err := lumber.NewLogger(lumber.LoggingConfig{EnableConsole: true}, true, lumber.InstanceZapLogger)
+	if err != nil {
+		log.Fatalf("Could not instantiate logger %s", err.Error())
+	}
+	gm := &gitManager{logger: logger}
+
+	srcDir := t.TempDir()
+	repoDir := filepath.Join(srcDir, "repo-"+commitID)
+	files := map[string]string{
+		"package.json":       `{"name": "repo"}`,
+		"test/math.spec.js":  "describe('math', () => {})",
+		"src/utils/index.js": "module.exports = {}",
+	}
+	for name, content := range files {
+		path := filepath.Join(repoDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+	}
+	archives := map[string]archiver.Archiver{
+		"zip":    archiver.NewZip(),
+		"tar.gz": archiver.NewTarGz(),
+	}
+	for format, a := range archives {
+		if err := a.Archive([]string{repoDir}, filepath.Join(srcDir, commitID+"."+format)); err != nil {
+			t.Fatalf("failed to create %s archive: %v", format, err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		archive  string
+		fileName string
+	}{
+		{"zip", commitID + ".zip", commitID + ".zip"},
+		{"tar.gz", commitID + ".tar.gz", commitID + ".tar.gz"},
+		{"tar.gz served for zip", commitID + ".tar.gz", commitID + ".zip"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := os.Open(filepath.Join(srcDir, tt.archive))
+			if err != nil {
+				t.Fatalf("failed to open archive: %v", err)
+			}
+			defer f.Close()
+
+			destDir := t.TempDir()
+			err = gm.copyAndExtractFile(&http.Response{Body: f}, filepath.Join(destDir, tt.fileName), destDir)
+			if err != nil {
+				t.Errorf("copyAndExtractFile() error = %v", err)
+				return
+			}
+			assert.Equal(t, listFiles(t, repoDir), listFiles(t, filepath.Join(destDir, "repo-"+commitID)))
+		})
+	}
+}
+
+// listFiles returns the relative paths of all the files under root, outside of the skipped dirs
+func listFiles(t *testing.T, root string, skipDirs ...string) []string {
+	var files []string
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			for _, dir := range skipDirs {
+				if info.Name() == dir {
+					return filepath.SkipDir
+				}
+			}
+		} else {
+			rel, _ := filepath.Rel(root, path)
+			files = append(files, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to walk dir %s: %v", root, err)
+	}
+	sort.Strings(files)
+	return files
+}
+
+func TestCheckRequiredPaths(t *testing.T) {
+	repoDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(repoDir, "test"), 0755); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(repoDir, "package.json"), []byte("{}"), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	assert.Nil(t, checkRequiredPaths(repoDir, nil))
+	assert.Nil(t, checkRequiredPaths(repoDir, []string{"package.json", "test"}))
+
+	err := checkRequiredPaths(repoDir, []string{"package.json", "yarn.lock"})
+	assert.ErrorIs(t, err, errs.ErrPostCloneCheck)
+	assert.Contains(t, err.Error(), "yarn.lock")
+}
+
+func TestClone(t *testing.T) {
+	logger, err := lumber.NewLogger(lumber.LoggingConfig{EnableConsole: true}, true, lumber.InstanceZapLogger)
+	if err != nil {
+		log.Fatalf("Could not instantiate logger %s", err.Error())
+	}
+	t.Setenv("GIT_AUTHOR_NAME", "tas")
+	t.Setenv("GIT_AUTHOR_EMAIL", "tas@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "tas")
+	t.Setenv("GIT_COMMITTER_EMAIL", "tas@example.com")
+
+	// origin repo with two commits, the second one is cloned
+	originDir := filepath.Join(t.TempDir(), "repo")
+	git := func(args ...string) string {
+		out, err := exec.Command("git", append([]string{"-C", originDir}, args...)...).CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v failed: %v: %s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	if err := os.MkdirAll(originDir, 0755); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	git("init", "--quiet")
+	for _, name := range []string{"package.json", "test/math.spec.js"} {
+		path := filepath.Join(originDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+		if err := ioutil.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+		git("add", name)
+		git("commit", "--quiet", "-m", "add "+name)
+	}
+	targetCommit := git("rev-parse", "HEAD")
+
+	// archive of the target commit, served like a github archive
+	archiveDir := filepath.Join(t.TempDir(), "repo-"+targetCommit)
+	git("worktree", "add", "--quiet", "--detach", archiveDir, targetCommit)
+	if err := os.Remove(filepath.Join(archiveDir, ".git")); err != nil {
+		t.Fatalf("failed to remove worktree metadata: %v", err)
+	}
+	archivePath := filepath.Join(t.TempDir(), targetCommit+".zip")
+	if err := archiver.NewZip().Archive([]string{archiveDir}, archivePath); err != nil {
+		t.Fatalf("failed to create archive: %v", err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/org/repo/archive/"+targetCommit+".zip" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		http.ServeFile(w, r, archivePath)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name        string
+		method      string
+		repoLink    string
+		depth       int
+		wantCommits string
+		wantErr     error
+	}{
+		{"archive", global.CloneMethodArchive, server.URL + "/org/repo", 0, "", nil},
+		{"git full history", global.CloneMethodGit, "file://" + originDir, 0, "2", nil},
+		{"git shallow", global.CloneMethodGit, "file://" + originDir, 1, "1", nil},
+		{"unsupported method", "svn", "file://" + originDir, 0, "", errs.ErrUnsupportedCloneMethod},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gm := &gitManager{
+				logger:          logger,
+				archiveFormat:   global.ArchiveFormatZip,
+				postCloneChecks: []string{"package.json"},
+				tempDir:         t.TempDir(),
+				repoDir:         filepath.Join(t.TempDir(), "repo"),
+				cloneMethod:     tt.method,
+				cloneDepth:      tt.depth,
+			}
+			payload := &core.Payload{GitProvider: core.GitHub, RepoLink: tt.repoLink, TargetCommit: targetCommit}
+			err := gm.Clone(context.TODO(), payload, "")
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				t.Fatalf("Clone() error = %v", err)
+			}
+			assert.Equal(t, []string{"package.json", filepath.Join("test", "math.spec.js")}, listFiles(t, gm.repoDir, ".git"))
+			if tt.wantCommits == "" {
+				assert.NoDirExists(t, filepath.Join(gm.repoDir, ".git"))
+				return
+			}
+			out, err := exec.Command("git", "-C", gm.repoDir, "rev-parse", "HEAD").Output()
+			assert.Nil(t, err)
+			assert.Equal(t, targetCommit, strings.TrimSpace(string(out)))
+			out, err = exec.Command("git", "-C", gm.repoDir, "rev-list", "--count", "HEAD").Output()
+			assert.Nil(t, err)
+			assert.Equal(t, tt.wantCommits, strings.TrimSpace(string(out)))
+		})
+	}
+}
+
+func TestGitAuthEnv(t *testing.T) {
+	assert.Equal(t, []string{"GIT_TERMINAL_PROMPT=0"}, gitAuthEnv(core.GitHub, ""))
+	env := gitAuthEnv(core.GitLab, "token")
+	// oauth2:token
+	assert.Contains(t, env, "GIT_CONFIG_VALUE_0=Authorization: Basic b2F1dGgyOnRva2Vu")
+}
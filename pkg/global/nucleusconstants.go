@@ -1,6 +1,10 @@
 package global
 
-import "time"
+import (
+	"fmt"
+	"strings"
+	"time"
+)
 
 // All constant related to nucleus
 const (
@@ -17,6 +21,176 @@ const (
 	SecretRegex              = `\${{\s*secrets\.(.*?)\s*}}`
 	ExecutionResultChunkSize = 50
 	TestLocatorsDelimiter    = "#TAS#"
+	// CommandMetadataKey is the key of the report metadata holding the masked command line of the runner
+	CommandMetadataKey = "tas.command"
+	// MaxMetadataSize is the maximum combined size in bytes of the build metadata keys and values
+	MaxMetadataSize = 4096
+	// OfflineDir is the default directory where the results are written in offline mode
+	OfflineDir = HomeDir + "/offline"
+	// OfflineTestListFile is the file in which the discovered tests are written in offline mode
+	OfflineTestListFile = "test-list.json"
+	// OfflineReportFile is the file in which the execution report is written in offline mode
+	OfflineReportFile = "report.json"
+	// WorkspaceSnapshotFile is the blob name of the workspace snapshot of a failed task
+	WorkspaceSnapshotFile = "workspace.tzst"
+	// PayloadSchemaVersion is the current version of the nucleus payload schema
+	PayloadSchemaVersion = 2
+	// MinPayloadSchemaVersion is the oldest payload schema version which is still supported
+	MinPayloadSchemaVersion = 1
+	// MaxTestOutputSize is the maximum size in bytes of the output attached to a failed test
+	MaxTestOutputSize = 64 << 10
+	// MB is the number of bytes in a megabyte
+	MB = 1 << 20
+	// DefaultGitUserName is the name of the git identity used by the user's commands
+	DefaultGitUserName = "TAS Bot"
+	// DefaultGitUserEmail is the email of the git identity used by the user's commands
+	DefaultGitUserEmail = "tas-bot@lambdatest.com"
+	// DefaultSkipTestsToken is the commit message token skipping the tests by default
+	DefaultSkipTestsToken = "[skip tests]"
+	// NvmSourceExitCode is the exit code of nvm when a version is not installed, e.g. when sourcing it in a
+	// directory with a .nvmrc of a missing version, see https://github.com/nvm-sh/nvm/issues/1985
+	NvmSourceExitCode = 3
+)
+
+// Files of the work directory recording the decisions of discovery and execution
+const (
+	// WorkDiffFile records the changed files and whether all the tests are discovered
+	WorkDiffFile = "discovery/diff.json"
+	// WorkDiscoveryCommandFile records the discovery command
+	WorkDiscoveryCommandFile = "discovery/command.json"
+	// WorkAssignmentFile records the tests assigned to the task
+	WorkAssignmentFile = "execution/assignment.json"
+)
+
+// Feature flags sent by the orchestrator in the payload to roll out behaviors per build
+const (
+	// FeatureSampledReports set to false turns off the sampling of the passed test results configured
+	// by passedResultSample for the build
+	FeatureSampledReports = "sampled_reports"
+)
+
+// Fallbacks for discovery when the diff of a pull request is empty
+const (
+	// EmptyDiffDiscoverAll discovers all the tests
+	EmptyDiffDiscoverAll = "all"
+	// EmptyDiffDiscoverNone discovers no tests
+	EmptyDiffDiscoverNone = "none"
+)
+
+// Behaviours when the blocklist can not be fetched from neuron
+const (
+	// BlocklistFailureStrict fails the task
+	BlocklistFailureStrict = "strict"
+	// BlocklistFailureLenient logs a warning and continues without the remote blocklist
+	BlocklistFailureLenient = "lenient"
+)
+
+// Archive formats in which the repo can be cloned
+const (
+	// ArchiveFormatZip clones the repo as a zip archive
+	ArchiveFormatZip = "zip"
+	// ArchiveFormatTarGz clones the repo as a gzipped tarball
+	ArchiveFormatTarGz = "tar.gz"
+)
+
+// Methods by which the repo can be cloned
+const (
+	// CloneMethodArchive downloads and extracts the archive of the target commit, without the git metadata
+	CloneMethodArchive = "archive"
+	// CloneMethodGit fetches the target commit with git
+	CloneMethodGit = "git"
+)
+
+// Schemes of the payload address selecting where the payload is fetched from
+const (
+	// PayloadSchemeFile reads the payload from a local JSON file
+	PayloadSchemeFile = "file"
+	// PayloadSchemeEnv reads the payload from an environment variable
+	PayloadSchemeEnv = "env"
+	// PayloadSchemeHTTP and PayloadSchemeHTTPS download the payload from the payload container
+	PayloadSchemeHTTP  = "http"
+	PayloadSchemeHTTPS = "https"
+)
+
+// Handling of the symlinks in the cloned repo
+const (
+	// SymlinkModePreserve keeps the symlinks of the cloned repo
+	SymlinkModePreserve = "preserve"
+	// SymlinkModeMaterialize replaces the symlinks to files of the cloned repo by copies of the files
+	SymlinkModeMaterialize = "materialize"
+)
+
+// Statuses of an execution task without any test results
+const (
+	// EmptySuitePassed marks the task as passed
+	EmptySuitePassed = "passed"
+	// EmptySuiteNoTests marks the task with the distinct no-tests status
+	EmptySuiteNoTests = "no-tests"
+	// EmptySuiteFailed marks the task as failed
+	EmptySuiteFailed = "failed"
+)
+
+// Handling of the test results with an unknown or empty status
+const (
+	// UnknownStatusError fails the task with an error
+	UnknownStatusError = "error"
+	// UnknownStatusFailed marks the task as failed
+	UnknownStatusFailed = "failed"
+	// UnknownStatusIgnore ignores the test result, as if it passed
+	UnknownStatusIgnore = "ignore"
+)
+
+// Rules picking the final result of a retried test
+const (
+	// RetryResultLast uses the result of the last attempt
+	RetryResultLast = "last"
+	// RetryResultAnyPass uses a passed attempt if there is one, otherwise the last attempt
+	RetryResultAnyPass = "any-pass"
+)
+
+// Handling of the parallelism set by the operator through the TAS_PARALLELISM_OVERRIDE env var
+const (
+	// ParallelismOverrideCap limits the parallelism of the tas configuration file to the env var
+	ParallelismOverrideCap = "cap"
+	// ParallelismOverrideReplace replaces the parallelism of the tas configuration file by the env var
+	ParallelismOverrideReplace = "replace"
+	// ParallelismOverrideEnv is the env var through which the operator overrides the parallelism
+	ParallelismOverrideEnv = "TAS_PARALLELISM_OVERRIDE"
+	// ParallelismEnv is the env var passing the effective parallelism to the runners
+	ParallelismEnv = "TAS_PARALLELISM"
+)
+
+// Versioning of the report sent to neuron
+const (
+	// ReportSchemaVersion is the schema version of the report. Version 1 is the original report,
+	// version 2 adds the metadata, runner version, warnings, execution seed and the attempts
+	// and output of the tests, version 3 adds the sampling of the passed tests, version 4 adds
+	// the snapshot of the environment.
+	ReportSchemaVersion = 4
+	// ReportSchemaHeader declares the schema version of the report, neuron advertises the version
+	// it supports through the same header of the response
+	ReportSchemaHeader = "X-Report-Schema-Version"
+)
+
+// Orders in which the tests are executed
+const (
+	// ExecutionOrderDeclared runs the tests in the order the framework finds them
+	ExecutionOrderDeclared = "declared"
+	// ExecutionOrderAlphabetical runs the test files sorted by name
+	ExecutionOrderAlphabetical = "alphabetical"
+	// ExecutionOrderRandom shuffles the tests with a seed reported in the results
+	ExecutionOrderRandom = "random"
+)
+
+// Env vars through which the execution options of the tas configuration are passed to the framework
+// runners, which map them to the options of their framework. Runners without the option ignore them.
+const (
+	// ExecConcurrencyEnv is the number of concurrent test workers
+	ExecConcurrencyEnv = "TAS_EXEC_CONCURRENCY"
+	// ExecutionOrderEnv is the order in which the tests are executed
+	ExecutionOrderEnv = "TAS_EXECUTION_ORDER"
+	// ExecutionSeedEnv is the seed of the random execution order
+	ExecutionSeedEnv = "TAS_EXECUTION_SEED"
 )
 
 // FrameworkRunnerMap is map of framework with there respective runner location
@@ -26,6 +200,62 @@ var FrameworkRunnerMap = map[string]string{
 	"jest":    "./node_modules/.bin/jest-runner",
 }
 
+// ArgTemplate is the template of the arguments passed to a framework runner for a value,
+// the arguments containing a %s verb are formatted with the value
+type ArgTemplate []string
+
+// Render returns the arguments of the template for the value
+func (t ArgTemplate) Render(value string) []string {
+	args := make([]string, 0, len(t))
+	for _, arg := range t {
+		if strings.Contains(arg, "%s") {
+			arg = fmt.Sprintf(arg, value)
+		}
+		args = append(args, arg)
+	}
+	return args
+}
+
+// DiscoveryArgs are the argument templates of the discovery command of a framework runner
+type DiscoveryArgs struct {
+	// Command selects the discovery, it has no value
+	Command ArgTemplate
+	// Diff is rendered for each changed file
+	Diff ArgTemplate
+	// EmptyDiff tells that nothing has changed, it has no value
+	EmptyDiff ArgTemplate
+	// Config is rendered for the config file of the framework
+	Config ArgTemplate
+	// Pattern is rendered for each glob pattern of the test files
+	Pattern ArgTemplate
+}
+
+// DefaultDiscoveryArgs are the discovery arguments of the runners of the LambdaTest test-at-scale
+// framework integrations
+var DefaultDiscoveryArgs = DiscoveryArgs{
+	Command:   ArgTemplate{"--command", "discover"},
+	Diff:      ArgTemplate{"--diff", "%s"},
+	EmptyDiff: ArgTemplate{"--diff"},
+	Config:    ArgTemplate{"--config", "%s"},
+	Pattern:   ArgTemplate{"--pattern", "%s"},
+}
+
+// FrameworkDiscoveryArgs is map of framework with the discovery arguments of their runner,
+// frameworks without an entry use DefaultDiscoveryArgs
+var FrameworkDiscoveryArgs = map[string]DiscoveryArgs{
+	"jasmine": DefaultDiscoveryArgs,
+	"mocha":   DefaultDiscoveryArgs,
+	"jest":    DefaultDiscoveryArgs,
+}
+
+// FrameworkEnvMap is map of framework with the default env vars of their discovery and execution,
+// the env vars of the tas configuration file and of the container take precedence over them
+var FrameworkEnvMap = map[string]map[string]string{
+	"jasmine": {"CI": "true", "NODE_ENV": "test"},
+	"mocha":   {"CI": "true", "NODE_ENV": "test"},
+	"jest":    {"CI": "true", "NODE_ENV": "test", "FORCE_COLOR": "0"},
+}
+
 // RawContentURLMap is map of git provider with there raw content url
 var RawContentURLMap = map[string]string{
 	"github": "https://raw.githubusercontent.com",
@@ -47,3 +277,36 @@ var NeuronHost string
 func SetNeuronHost(host string) {
 	NeuronHost = host
 }
+
+// NeuronHeaders are the headers added to every request to neuron
+var NeuronHeaders map[string]string
+
+// SetNeuronHeaders is setter for NeuronHeaders
+func SetNeuronHeaders(headers map[string]string) {
+	NeuronHeaders = headers
+}
+
+// CorrelationIDHeader is the header carrying the correlation ID of the build in the requests to neuron
+const CorrelationIDHeader = "X-Correlation-ID"
+
+// CorrelationID identifies the build in the logs and the requests to neuron
+var CorrelationID string
+
+// SetCorrelationID is setter for CorrelationID
+func SetCorrelationID(id string) {
+	CorrelationID = id
+}
+
+// FailureOutputTail is the number of bytes at the end of the output of a failed command which are
+// matched against the failure classes
+const FailureOutputTail = 64 * 1024
+
+// DefaultFailureClasses classify the failures caused by the infrastructure as errors, which can be retried.
+// There is no default failed class: the classes only apply when the runner itself fails, in which case
+// no test results are reported, so an assertion in its output does not make the task a test failure.
+var DefaultFailureClasses = []string{
+	`error=JavaScript heap out of memory`,
+	`error=\bENOMEM\b|Cannot allocate memory|signal: killed`,
+	`error=\bENOSPC\b|[Nn]o space left on device`,
+	`error=\b(ECONNRESET|ETIMEDOUT|ECONNREFUSED|EAI_AGAIN)\b`,
+}
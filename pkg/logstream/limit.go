@@ -0,0 +1,74 @@
+package logstream
+
+import (
+	"fmt"
+	"io"
+)
+
+// limiter caps the output written to the base writer. The first half of the limit is written
+// as is, the last half is buffered and written on Close, with a marker in place of the elided bytes.
+type limiter struct {
+	w        io.Writer
+	headLeft int
+	tailSize int
+	tail     []byte
+	elided   int64
+}
+
+// NewLimiter returns a writer which writes at most limit bytes of the output to w, keeping its head
+// and tail. It must be closed to flush the tail. A limit of 0 or less disables the limit.
+func NewLimiter(w io.Writer, limit int) io.WriteCloser {
+	if limit <= 0 {
+		return nopCloser{w}
+	}
+	return &limiter{w: w, headLeft: limit - limit/2, tailSize: limit / 2}
+}
+
+// Write writes the head of the output to the base writer and buffers the rest
+func (l *limiter) Write(p []byte) (n int, err error) {
+	n = len(p)
+	if l.headLeft > 0 {
+		head := p
+		if len(head) > l.headLeft {
+			head = head[:l.headLeft]
+		}
+		l.headLeft -= len(head)
+		p = p[len(head):]
+		if _, err = l.w.Write(head); err != nil {
+			return n, err
+		}
+	}
+	if len(p) == 0 {
+		return n, nil
+	}
+	l.tail = append(l.tail, p...)
+	if over := len(l.tail) - l.tailSize; over > 0 {
+		l.elided += int64(over)
+		l.tail = l.tail[over:]
+	}
+	return n, nil
+}
+
+// Close writes the elision marker and the buffered tail to the base writer
+func (l *limiter) Close() error {
+	if l.elided > 0 {
+		if _, err := fmt.Fprintf(l.w, "\n... %d bytes of output elided ...\n", l.elided); err != nil {
+			return err
+		}
+		l.elided = 0
+	}
+	if len(l.tail) > 0 {
+		tail := l.tail
+		l.tail = nil
+		if _, err := l.w.Write(tail); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
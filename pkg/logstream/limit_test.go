@@ -0,0 +1,55 @@
+package logstream
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimiter(t *testing.T) {
+	tests := []struct {
+		name  string
+		limit int
+		input []string
+		want  string
+	}{
+		{"within limit", 10, []string{"abc", "def"}, "abcdef"},
+		{"disabled", 0, []string{"abc", "def"}, "abcdef"},
+		{"head and tail", 6, []string{"abcd", "efgh", "ijkl"}, "abc\n... 6 bytes of output elided ...\njkl"},
+		{"tail of a single write", 4, []string{"abcdefgh"}, "ab\n... 4 bytes of output elided ...\ngh"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			l := NewLimiter(&buf, tt.limit)
+			for _, in := range tt.input {
+				n, err := l.Write([]byte(in))
+				assert.Nil(t, err)
+				assert.Equal(t, len(in), n)
+			}
+			assert.Nil(t, l.Close())
+			assert.Equal(t, tt.want, buf.String())
+			// closing again writes nothing
+			assert.Nil(t, l.Close())
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
+
+func TestLimiterLargeStream(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLimiter(&buf, 1<<20)
+	line := strings.Repeat("x", 1023) + "\n"
+	for i := 0; i < 64*1024; i++ {
+		if _, err := l.Write([]byte(line)); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+	assert.Nil(t, l.Close())
+
+	marker := "\n... 66060288 bytes of output elided ...\n"
+	assert.Equal(t, 1<<20+len(marker), buf.Len())
+	assert.Equal(t, strings.Repeat(line, 512)+marker+strings.Repeat(line, 512), buf.String())
+}
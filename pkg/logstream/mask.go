@@ -1,18 +1,72 @@
 package logstream
 
 import (
+	"encoding/base64"
 	"io"
+	"net/url"
+	"regexp"
 	"strings"
 )
 
 const (
 	maskedStr = "****************"
+	// pathPlaceholder replaces the redacted paths
+	pathPlaceholder = "<redacted-path>"
 )
 
+// defaultMaskPatterns matches common credential formats which should never be
+// printed in the logs, even if they are not part of the secret map.
+var defaultMaskPatterns = []string{
+	// GitHub personal access, OAuth, user-to-server, server-to-server and refresh tokens
+	`gh[pousr]_[A-Za-z0-9]{36}`,
+	// GitHub fine-grained personal access tokens
+	`github_pat_[A-Za-z0-9_]{82}`,
+	// GitLab personal access tokens
+	`glpat-[A-Za-z0-9_\-]{20}`,
+	// AWS access key IDs
+	`AKIA[0-9A-Z]{16}`,
+	// Slack tokens
+	`xox[baprs]-[A-Za-z0-9-]{10,}`,
+	// npm access tokens
+	`npm_[A-Za-z0-9]{36}`,
+}
+
+// maskPatterns holds the compiled patterns applied by every masker
+var maskPatterns = mustCompilePatterns(defaultMaskPatterns)
+
+// pathPatterns match the paths redacted by every masker
+var pathPatterns []*regexp.Regexp
+
 // masker wraps a stream writer with a masker
 type masker struct {
-	w io.Writer
-	r *strings.Replacer
+	w        io.Writer
+	r        *strings.Replacer
+	patterns []*regexp.Regexp
+	paths    []*regexp.Regexp
+}
+
+// RegisterPatterns adds user defined regex patterns to the set of patterns masked
+// by every masker. It is expected to be called once at startup.
+func RegisterPatterns(exprs []string) error {
+	for _, expr := range exprs {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return err
+		}
+		maskPatterns = append(maskPatterns, re)
+	}
+	return nil
+}
+
+// RegisterPathPrefixes redacts the paths starting with one of the prefixes in the output of
+// every masker, up to the end of the path or a line number. It is expected to be called once at startup.
+func RegisterPathPrefixes(prefixes []string) {
+	for _, prefix := range prefixes {
+		if prefix == "" {
+			continue
+		}
+		pathPatterns = append(pathPatterns, regexp.MustCompile(regexp.QuoteMeta(prefix)+"[^\\s\"'`:,;()\\[\\]]*"))
+	}
 }
 
 // NewMasker returns a masker that wraps io.Writer w.
@@ -30,19 +84,51 @@ func NewMasker(w io.Writer, secretData map[string]string) io.Writer {
 			}
 			oldnew = append(oldnew, part, maskedStr)
 		}
+		// mask the encoded forms of the secret as well
+		for _, encoded := range []string{base64.StdEncoding.EncodeToString([]byte(secret)), url.QueryEscape(secret)} {
+			if len(secret) < 2 || encoded == secret {
+				continue
+			}
+			oldnew = append(oldnew, encoded, maskedStr)
+		}
 	}
-	if len(oldnew) == 0 {
+	if len(oldnew) == 0 && len(maskPatterns) == 0 && len(pathPatterns) == 0 {
 		return w
 	}
 	return &masker{
-		w: w,
-		r: strings.NewReplacer(oldnew...),
+		w:        w,
+		r:        strings.NewReplacer(oldnew...),
+		patterns: maskPatterns,
+		paths:    pathPatterns,
 	}
 }
 
 // Write writes p to the base writer. The method scans for any
 // sensitive data in p and masks before writing.
 func (m *masker) Write(p []byte) (n int, err error) {
-	_, err = m.w.Write([]byte(m.r.Replace(string(p))))
+	masked := m.r.Replace(string(p))
+	for _, re := range m.patterns {
+		masked = re.ReplaceAllString(masked, maskedStr)
+	}
+	for _, re := range m.paths {
+		masked = re.ReplaceAllString(masked, pathPlaceholder)
+	}
+	_, err = m.w.Write([]byte(masked))
 	return len(p), err
 }
+
+// MaskString returns s with the secrets and the registered patterns masked, as a masker would write it
+func MaskString(s string, secretData map[string]string) string {
+	var b strings.Builder
+	// writing to a strings.Builder never fails
+	NewMasker(&b, secretData).Write([]byte(s)) // nolint:errcheck
+	return b.String()
+}
+
+func mustCompilePatterns(exprs []string) []*regexp.Regexp {
+	patterns := make([]*regexp.Regexp, 0, len(exprs))
+	for _, expr := range exprs {
+		patterns = append(patterns, regexp.MustCompile(expr))
+	}
+	return patterns
+}
@@ -0,0 +1,33 @@
+package logstream
+
+import "sync"
+
+// Tail is a writer which keeps the last bytes of the output written to it
+type Tail struct {
+	mu   sync.Mutex
+	size int
+	buf  []byte
+}
+
+// NewTail returns a writer keeping the last size bytes of the output
+func NewTail(size int) *Tail {
+	return &Tail{size: size}
+}
+
+// Write buffers p, dropping the oldest bytes above the size
+func (t *Tail) Write(p []byte) (n int, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.buf = append(t.buf, p...)
+	if over := len(t.buf) - t.size; over > 0 {
+		t.buf = t.buf[over:]
+	}
+	return len(p), nil
+}
+
+// String returns the buffered output
+func (t *Tail) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return string(t.buf)
+}
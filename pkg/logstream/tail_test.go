@@ -0,0 +1,31 @@
+package logstream
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTail(t *testing.T) {
+	tests := []struct {
+		name  string
+		size  int
+		input []string
+		want  string
+	}{
+		{"within size", 10, []string{"abc", "def"}, "abcdef"},
+		{"oldest bytes dropped", 4, []string{"abc", "def"}, "cdef"},
+		{"single write above size", 3, []string{"abcdefgh"}, "fgh"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tail := NewTail(tt.size)
+			for _, in := range tt.input {
+				n, err := tail.Write([]byte(in))
+				assert.Nil(t, err)
+				assert.Equal(t, len(in), n)
+			}
+			assert.Equal(t, tt.want, tail.String())
+		})
+	}
+}
@@ -6,14 +6,17 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/url"
+	"os"
 	"strings"
 	"time"
 
 	"github.com/LambdaTest/synapse/config"
 	"github.com/LambdaTest/synapse/pkg/core"
 	"github.com/LambdaTest/synapse/pkg/errs"
+	"github.com/LambdaTest/synapse/pkg/global"
 	"github.com/LambdaTest/synapse/pkg/lumber"
 )
 
@@ -40,15 +43,34 @@ func NewPayloadManger(azureClient core.AzureClient,
 	return &pm
 }
 
+// FetchPayload fetches the payload from the source selected by the scheme of the address: file:// reads a
+// local JSON file, env://VAR reads the JSON from the environment variable VAR and http(s):// downloads it
+// from the payload container. Addresses without a scheme are read as local files in offline mode.
 func (pm *payloadManager) FetchPayload(ctx context.Context, payloadAddress string) (*core.Payload, error) {
 	if payloadAddress == "" {
 		return nil, errors.New("invalid payload address")
 	}
-
 	u, err := url.Parse(payloadAddress)
 	if err != nil {
 		return nil, err
 	}
+	switch u.Scheme {
+	case global.PayloadSchemeFile:
+		return pm.readPayload(payloadAddress)
+	case global.PayloadSchemeEnv:
+		return pm.readPayloadEnv(strings.TrimPrefix(payloadAddress, global.PayloadSchemeEnv+"://"))
+	case global.PayloadSchemeHTTP, global.PayloadSchemeHTTPS:
+		return pm.downloadPayload(ctx, u)
+	case "":
+		if pm.cfg.Offline {
+			return pm.readPayload(payloadAddress)
+		}
+	}
+	return nil, fmt.Errorf("%w: %q", errs.ErrUnsupportedPayloadScheme, u.Scheme)
+}
+
+// downloadPayload downloads the payload from the payload container
+func (pm *payloadManager) downloadPayload(ctx context.Context, u *url.URL) (*core.Payload, error) {
 	// string the container name to get blob path
 	blobPath := strings.Replace(u.Path, fmt.Sprintf("/%s/", core.PayloadContainer), "", -1)
 
@@ -76,36 +98,69 @@ func (pm *payloadManager) FetchPayload(ctx context.Context, payloadAddress strin
 
 }
 
-func (pm *payloadManager) ValidatePayload(ctx context.Context, payload *core.Payload) error {
-	if payload.RepoLink == "" {
-		return errs.ErrInvalidPayload("Missing repo link")
+// readPayload reads the payload from a local file
+func (pm *payloadManager) readPayload(path string) (*core.Payload, error) {
+	rawBytes, err := ioutil.ReadFile(strings.TrimPrefix(path, "file://"))
+	if err != nil {
+		return nil, err
 	}
-
-	if payload.RepoSlug == "" {
-		return errs.ErrInvalidPayload("Missing repo slug")
+	var p core.Payload
+	if err := json.Unmarshal(rawBytes, &p); err != nil {
+		return nil, err
 	}
+	return &p, nil
+}
 
-	if payload.GitProvider == "" {
-		return errs.ErrInvalidPayload("Missing git provider")
+// readPayloadEnv reads the payload from the environment variable name
+func (pm *payloadManager) readPayloadEnv(name string) (*core.Payload, error) {
+	if name == "" {
+		return nil, errors.New("missing environment variable in payload address")
 	}
-
-	if payload.BuildID == "" {
-		return errs.ErrInvalidPayload("Missing BuildID")
+	raw, ok := os.LookupEnv(name)
+	if !ok {
+		return nil, fmt.Errorf("environment variable %s for the payload is not set", name)
 	}
-	if payload.RepoID == "" {
-		return errs.ErrInvalidPayload("Missing RepoID")
+	var p core.Payload
+	if err := json.Unmarshal([]byte(raw), &p); err != nil {
+		return nil, err
 	}
+	return &p, nil
+}
 
-	if payload.BranchName == "" {
-		return errs.ErrInvalidPayload("Missing Branch Name")
+func (pm *payloadManager) ValidatePayload(ctx context.Context, payload *core.Payload) error {
+	if err := upgradePayload(payload); err != nil {
+		return err
+	}
+
+	requiredFields := []struct {
+		name  string
+		value string
+	}{
+		{"repo_link", payload.RepoLink},
+		{"repo_slug", payload.RepoSlug},
+		{"git_provider", payload.GitProvider},
+		{"build_id", payload.BuildID},
+		{"repo_id", payload.RepoID},
+		{"branch_name", payload.BranchName},
+		{"org_id", payload.OrgID},
+		{"tas_file_name", payload.TasFileName},
+		{"build_target_commit", payload.BuildTargetCommit},
+		{"license_tier", string(payload.LicenseTier)},
+	}
+	var missing []string
+	for _, field := range requiredFields {
+		if field.value == "" {
+			missing = append(missing, field.name)
+		}
 	}
-
-	if payload.OrgID == "" {
-		return errs.ErrInvalidPayload("Missing OrgID")
+	if len(missing) > 0 {
+		return errs.ErrInvalidPayload(fmt.Sprintf("Missing required fields in payload: %s", strings.Join(missing, ", ")))
 	}
 
-	if payload.TasFileName == "" {
-		return errs.ErrInvalidPayload("Missing tas yml filename")
+	switch payload.LicenseTier {
+	case core.Internal, core.XSmall, core.Small, core.Medium, core.Large, core.XLarge:
+	default:
+		return errs.ErrInvalidPayload(fmt.Sprintf("Invalid value %q for field license_tier", payload.LicenseTier))
 	}
 
 	if pm.cfg.Locators != "" {
@@ -115,8 +170,9 @@ func (pm *payloadManager) ValidatePayload(ctx context.Context, payload *core.Pay
 	if pm.cfg.LocatorAddress != "" {
 		payload.LocatorAddress = pm.cfg.LocatorAddress
 	}
-	if payload.BuildTargetCommit == "" {
-		return errs.ErrInvalidPayload("Missing build target commit")
+
+	if pm.cfg.DiffBaseCommit != "" {
+		payload.DiffBaseCommit = pm.cfg.DiffBaseCommit
 	}
 	// some checks are removed in case of coverage mode or parsing mode
 	if !(pm.cfg.CoverageMode || pm.cfg.ParseMode) {
@@ -133,12 +189,59 @@ func (pm *payloadManager) ValidatePayload(ctx context.Context, payload *core.Pay
 	}
 
 	if payload.EventType != core.EventPush && payload.EventType != core.EventPullRequest {
-		return errs.ErrInvalidPayload("Invalid event type")
+		return errs.ErrInvalidPayload(fmt.Sprintf("Invalid value %q for field event_type", payload.EventType))
 	}
 
 	if payload.EventType == core.EventPush && len(payload.Commits) == 0 {
-		return errs.ErrInvalidPayload("Missing commits error")
+		return errs.ErrInvalidPayload("Missing required field commits for push event")
+	}
+
+	return pm.mergeMetadata(payload)
+}
+
+// upgradePayload checks the schema version of the payload and upgrades payloads
+// of the previous schema version to the current one
+func upgradePayload(payload *core.Payload) error {
+	// payloads sent before the schema was versioned do not have a version
+	if payload.SchemaVersion == 0 {
+		payload.SchemaVersion = 1
+	}
+	if payload.SchemaVersion < global.MinPayloadSchemaVersion || payload.SchemaVersion > global.PayloadSchemaVersion {
+		return errs.ErrInvalidPayload(fmt.Sprintf("Incompatible payload schema_version %d, supported versions are %d to %d",
+			payload.SchemaVersion, global.MinPayloadSchemaVersion, global.PayloadSchemaVersion))
+	}
+	if payload.SchemaVersion == 1 {
+		// license_tier is required since version 2, older payloads run on the default tier
+		if payload.LicenseTier == "" {
+			payload.LicenseTier = core.Small
+		}
+		payload.SchemaVersion = global.PayloadSchemaVersion
 	}
+	return nil
+}
 
+// mergeMetadata merges the metadata provided in config into the payload and validates it
+func (pm *payloadManager) mergeMetadata(payload *core.Payload) error {
+	for _, pair := range pm.cfg.Metadata {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 {
+			return errs.ErrInvalidPayload(fmt.Sprintf("Invalid metadata %q, expected key=value", pair))
+		}
+		if payload.Metadata == nil {
+			payload.Metadata = make(map[string]string)
+		}
+		payload.Metadata[kv[0]] = kv[1]
+	}
+
+	size := 0
+	for key, value := range payload.Metadata {
+		if strings.TrimSpace(key) == "" {
+			return errs.ErrInvalidPayload("Empty metadata key")
+		}
+		size += len(key) + len(value)
+	}
+	if size > global.MaxMetadataSize {
+		return errs.ErrInvalidPayload(fmt.Sprintf("Metadata exceeds the maximum size of %d bytes", global.MaxMetadataSize))
+	}
 	return nil
 }
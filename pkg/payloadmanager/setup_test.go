@@ -0,0 +1,181 @@
+package payloadmanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/LambdaTest/synapse/config"
+	"github.com/LambdaTest/synapse/pkg/core"
+	"github.com/LambdaTest/synapse/pkg/errs"
+	"github.com/LambdaTest/synapse/pkg/global"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeMetadata(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     []string
+		payload map[string]string
+		want    map[string]string
+		wantErr bool
+	}{
+		{"no metadata", nil, nil, nil, false},
+		{"payload metadata", nil, map[string]string{"deploy": "staging"}, map[string]string{"deploy": "staging"}, false},
+		{"config overrides payload", []string{"deploy=prod", "job=https://ci/1?a=b"}, map[string]string{"deploy": "staging"},
+			map[string]string{"deploy": "prod", "job": "https://ci/1?a=b"}, false},
+		{"malformed pair", []string{"deploy"}, nil, nil, true},
+		{"empty key", []string{"=prod"}, nil, nil, true},
+		{"too large", nil, map[string]string{"blob": strings.Repeat("x", global.MaxMetadataSize)}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pm := &payloadManager{cfg: &config.NucleusConfig{Metadata: tt.cfg}}
+			payload := &core.Payload{Metadata: tt.payload}
+			err := pm.mergeMetadata(payload)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("mergeMetadata() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr {
+				assert.Equal(t, tt.want, payload.Metadata)
+			}
+		})
+	}
+}
+
+func TestFetchPayloadOffline(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "payload.json")
+	if err := ioutil.WriteFile(path, []byte(`{"repo_slug": "org/repo", "event_type": "push"}`), 0644); err != nil {
+		t.Fatalf("failed to write payload: %v", err)
+	}
+	pm := &payloadManager{cfg: &config.NucleusConfig{Offline: true}}
+
+	for _, address := range []string{path, "file://" + path} {
+		payload, err := pm.FetchPayload(context.TODO(), address)
+		if err != nil {
+			t.Fatalf("FetchPayload() error = %v", err)
+		}
+		assert.Equal(t, "org/repo", payload.RepoSlug)
+		assert.Equal(t, core.EventPush, payload.EventType)
+	}
+}
+
+// blobAzureClient returns SAS URLs of the blobs served by the test server
+type blobAzureClient struct {
+	core.AzureClient
+	serverURL string
+}
+
+func (b *blobAzureClient) GetSASURL(ctx context.Context, containerPath string, containerType core.ContainerType) (string, error) {
+	return b.serverURL + "/" + containerPath, nil
+}
+
+func TestFetchPayload(t *testing.T) {
+	raw := `{"repo_slug": "org/repo", "event_type": "push"}`
+	path := filepath.Join(t.TempDir(), "payload.json")
+	if err := ioutil.WriteFile(path, []byte(raw), 0644); err != nil {
+		t.Fatalf("failed to write payload: %v", err)
+	}
+	t.Setenv("TAS_TEST_PAYLOAD", raw)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/build/payload.json" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, raw)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		address string
+		wantErr bool
+		errIs   error
+	}{
+		{"file", "file://" + path, false, nil},
+		{"env", "env://TAS_TEST_PAYLOAD", false, nil},
+		{"http", "http://payloads/" + string(core.PayloadContainer) + "/build/payload.json", false, nil},
+		{"https", "https://payloads/" + string(core.PayloadContainer) + "/build/payload.json", false, nil},
+		{"unset env", "env://TAS_TEST_MISSING_PAYLOAD", true, nil},
+		{"unsupported scheme", "ftp://payloads/build/payload.json", true, errs.ErrUnsupportedPayloadScheme},
+		{"no scheme", path, true, errs.ErrUnsupportedPayloadScheme},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pm := &payloadManager{
+				cfg:         &config.NucleusConfig{},
+				azureClient: &blobAzureClient{serverURL: server.URL},
+				httpClient:  *server.Client(),
+			}
+			payload, err := pm.FetchPayload(context.TODO(), tt.address)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FetchPayload() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if tt.errIs != nil {
+					assert.True(t, errors.Is(err, tt.errIs), "FetchPayload() error = %v, want %v", err, tt.errIs)
+				}
+				return
+			}
+			assert.Equal(t, "org/repo", payload.RepoSlug)
+			assert.Equal(t, core.EventPush, payload.EventType)
+		})
+	}
+}
+
+func TestValidatePayloadSchemaVersion(t *testing.T) {
+	newPayload := func(version int, tier core.Tier) *core.Payload {
+		return &core.Payload{
+			SchemaVersion:     version,
+			RepoSlug:          "org/repo",
+			RepoLink:          "https://github.com/org/repo",
+			BuildTargetCommit: "abc123",
+			BuildID:           "build",
+			RepoID:            "repo",
+			OrgID:             "org",
+			BranchName:        "main",
+			GitProvider:       core.GitHub,
+			EventType:         core.EventPush,
+			Commits:           []core.CommitChangeList{{}},
+			TasFileName:       ".tas.yml",
+			LicenseTier:       tier,
+		}
+	}
+
+	tests := []struct {
+		name     string
+		payload  *core.Payload
+		wantTier core.Tier
+		wantErr  string
+	}{
+		{"current version", newPayload(global.PayloadSchemaVersion, core.Medium), core.Medium, ""},
+		{"prior version", newPayload(1, ""), core.Small, ""},
+		{"unversioned", newPayload(0, ""), core.Small, ""},
+		{"incompatible version", newPayload(global.PayloadSchemaVersion+1, core.Small), "", "Incompatible payload schema_version 3"},
+		{"missing fields", &core.Payload{SchemaVersion: global.PayloadSchemaVersion, RepoSlug: "org/repo"}, "",
+			"Missing required fields in payload: repo_link, git_provider, build_id, repo_id, branch_name, org_id, tas_file_name, build_target_commit, license_tier"},
+		{"invalid tier", newPayload(global.PayloadSchemaVersion, "huge"), "", `Invalid value "huge" for field license_tier`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pm := &payloadManager{cfg: &config.NucleusConfig{TargetCommit: "abc123", TaskID: "task"}}
+			err := pm.ValidatePayload(context.TODO(), tt.payload)
+			if tt.wantErr != "" {
+				if assert.NotNil(t, err) {
+					assert.Contains(t, err.Error(), tt.wantErr)
+				}
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, global.PayloadSchemaVersion, tt.payload.SchemaVersion)
+			assert.Equal(t, tt.wantTier, tt.payload.LicenseTier)
+		})
+	}
+}
@@ -1,38 +1,60 @@
 package secret
 
 import (
+	"bufio"
+	"bytes"
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
+	"sync"
 
+	"github.com/LambdaTest/synapse/config"
 	"github.com/LambdaTest/synapse/pkg/core"
 	"github.com/LambdaTest/synapse/pkg/errs"
 	"github.com/LambdaTest/synapse/pkg/global"
 	"github.com/LambdaTest/synapse/pkg/lumber"
+	"github.com/LambdaTest/synapse/pkg/utils"
+	"gopkg.in/yaml.v2"
+)
+
+// supported formats of the repo secrets file
+const (
+	formatJSON   = "json"
+	formatDotenv = "dotenv"
+	formatYAML   = "yaml"
 )
 
 type secretParser struct {
 	logger      lumber.Logger
 	secretRegex *regexp.Regexp
+	strict      bool
+	mu          sync.Mutex
+	referenced  map[string]struct{}
 }
 
 type secretData struct {
-	SecretMap map[string]string `json:"data"`
+	SecretMap map[string]string `json:"data" yaml:"data"`
 }
 
 // New return new secret parser
-func New(logger lumber.Logger) core.SecretParser {
+func New(cfg *config.NucleusConfig, logger lumber.Logger) core.SecretParser {
 	return &secretParser{
 		logger:      logger,
 		secretRegex: regexp.MustCompile(global.SecretRegex),
+		strict:      cfg.StrictSecrets,
+		referenced:  make(map[string]struct{}),
 	}
 }
 
-// GetRepoSecret read repo secrets from given path
+// GetRepoSecret read repo secrets from given path.
+// The secrets can be in json, dotenv or yaml format which is detected from the
+// file extension, falling back to the file content.
 func (s *secretParser) GetRepoSecret(path string) (map[string]string, error) {
-	var secretData secretData
 	if _, err := os.Stat(path); os.IsNotExist(err) {
 		s.logger.Debugf("failed to find user env secrets in path %s, as path does not exists", path)
 		return nil, nil
@@ -42,13 +64,73 @@ func (s *secretParser) GetRepoSecret(path string) (map[string]string, error) {
 		return nil, err
 	}
 
-	if err = json.Unmarshal(body, &secretData); err != nil {
-		s.logger.Errorf("failed to unmarshal user env secrets, error %v", err)
+	format := detectSecretFormat(path, body)
+	secretMap, err := parseSecrets(format, body)
+	if err != nil {
+		s.logger.Errorf("failed to unmarshal user env secrets of format %s, error %v", format, err)
 		return nil, err
 	}
+	return secretMap, nil
+}
+
+// detectSecretFormat returns the format of the secrets file
+func detectSecretFormat(path string, body []byte) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return formatJSON
+	case ".env":
+		return formatDotenv
+	case ".yaml", ".yml":
+		return formatYAML
+	}
 
-	// extract secretmap from data map[data: map[secretname:secretvalue]]
-	return secretData.SecretMap, nil
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		return formatJSON
+	}
+	// the first significant line decides between KEY=VALUE and key: value
+	scanner := bufio.NewScanner(bytes.NewReader(trimmed))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		eq := strings.Index(line, "=")
+		colon := strings.Index(line, ":")
+		if eq != -1 && (colon == -1 || eq < colon) {
+			return formatDotenv
+		}
+		break
+	}
+	return formatYAML
+}
+
+// parseSecrets parses the body of the secrets file of the given format
+func parseSecrets(format string, body []byte) (map[string]string, error) {
+	switch format {
+	case formatJSON:
+		// extract secretmap from data map[data: map[secretname:secretvalue]]
+		var secretData secretData
+		if err := json.Unmarshal(body, &secretData); err != nil {
+			return nil, err
+		}
+		return secretData.SecretMap, nil
+	case formatYAML:
+		// secrets can either be nested inside data or be at the root of the file
+		var secretData secretData
+		if err := yaml.Unmarshal(body, &secretData); err == nil && secretData.SecretMap != nil {
+			return secretData.SecretMap, nil
+		}
+		secretMap := make(map[string]string)
+		if err := yaml.Unmarshal(body, &secretMap); err != nil {
+			return nil, err
+		}
+		return secretMap, nil
+	case formatDotenv:
+		return utils.ParseEnvFile(string(body))
+	default:
+		return nil, fmt.Errorf("unsupported secrets format %s", format)
+	}
 }
 
 // GetOauthSecret parses the oauth secret
@@ -81,8 +163,13 @@ func (s *secretParser) SubstituteSecret(command string, secretData map[string]st
 		if len(match) < 2 {
 			return "", errs.ErrSecretRegexMatch
 		}
+		s.markReferenced(match[1])
 		// validating secret key exists or not
 		if _, ok := secretData[match[1]]; !ok {
+			if s.strict {
+				s.logger.Errorf("secret with name %s not found in map", match[0])
+				return "", fmt.Errorf("%w: %s", errs.ErrUndefinedSecret, match[1])
+			}
 			s.logger.Warnf("secret with name %s not found in map", match[0])
 			continue
 		}
@@ -91,3 +178,23 @@ func (s *secretParser) SubstituteSecret(command string, secretData map[string]st
 
 	return result, nil
 }
+
+// UnusedSecrets returns the sorted names of the secrets which were never referenced
+func (s *secretParser) UnusedSecrets(secretData map[string]string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	unused := make([]string, 0)
+	for name := range secretData {
+		if _, ok := s.referenced[name]; !ok {
+			unused = append(unused, name)
+		}
+	}
+	sort.Strings(unused)
+	return unused
+}
+
+func (s *secretParser) markReferenced(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.referenced[name] = struct{}{}
+}
@@ -1,10 +1,15 @@
 package secret
 
 import (
+	"io/ioutil"
 	"log"
+	"path/filepath"
 	"testing"
 
+	"github.com/LambdaTest/synapse/config"
+	"github.com/LambdaTest/synapse/pkg/errs"
 	"github.com/LambdaTest/synapse/pkg/lumber"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestSubstituteSecret(t *testing.T) {
@@ -13,7 +18,7 @@ func TestSubstituteSecret(t *testing.T) {
 		log.Fatalf("Could not instantiate logger %s", err.Error())
 	}
 
-	secretParser := New(logger)
+	secretParser := New(&config.NucleusConfig{}, logger)
 	var expressions = []struct {
 		params    map[string]string
 		input     string
@@ -68,3 +73,59 @@ func TestSubstituteSecret(t *testing.T) {
 		})
 	}
 }
+
+func TestGetRepoSecret(t *testing.T) {
+	logger, err := lumber.NewLogger(lumber.LoggingConfig{EnableConsole: true}, true, lumber.InstanceZapLogger)
+	if err != nil {
+		log.Fatalf("Could not instantiate logger %s", err.Error())
+	}
+
+	secretParser := New(&config.NucleusConfig{}, logger)
+	want := map[string]string{"NPM_TOKEN": "secret", "AWS_KEY": "a#b $c"}
+	var files = []struct {
+		name    string
+		content string
+	}{
+		{"reposecrets", `{"data":{"NPM_TOKEN":"secret","AWS_KEY":"a#b $c"}}`},
+		{"secrets.json", `{"data":{"NPM_TOKEN":"secret","AWS_KEY":"a#b $c"}}`},
+		{"secrets.env", "# repo secrets\nexport NPM_TOKEN=secret # npm\n\nAWS_KEY=\"a#b $c\"\n"},
+		{"dotenv", "NPM_TOKEN='secret' # npm\nAWS_KEY=\"a#b $c\"#aws\n"},
+		{"secrets.yaml", "data:\n  NPM_TOKEN: secret\n  AWS_KEY: \"a#b $c\"\n"},
+		{"yaml", "# repo secrets\nNPM_TOKEN: secret\nAWS_KEY: 'a#b $c'\n"},
+	}
+
+	dir := t.TempDir()
+	for _, file := range files {
+		t.Run(file.name, func(t *testing.T) {
+			path := filepath.Join(dir, file.name)
+			if err := ioutil.WriteFile(path, []byte(file.content), 0644); err != nil {
+				t.Fatalf("failed to write secrets file: %v", err)
+			}
+			got, err := secretParser.GetRepoSecret(path)
+			if err != nil {
+				t.Fatalf("failed to get repo secrets: %v", err)
+			}
+			assert.Equal(t, want, got)
+		})
+	}
+}
+
+func TestStrictSecrets(t *testing.T) {
+	logger, err := lumber.NewLogger(lumber.LoggingConfig{EnableConsole: true}, true, lumber.InstanceZapLogger)
+	if err != nil {
+		log.Fatalf("Could not instantiate logger %s", err.Error())
+	}
+
+	secretParser := New(&config.NucleusConfig{StrictSecrets: true}, logger)
+	secretData := map[string]string{"NPM_TOKEN": "secret", "TAG": "nucleus", "UNUSED": "value"}
+
+	output, err := secretParser.SubstituteSecret("npm publish --tag=${{ secrets.TAG }}", secretData)
+	assert.Nil(t, err)
+	assert.Equal(t, "npm publish --tag=nucleus", output)
+
+	_, err = secretParser.SubstituteSecret("echo ${{ secrets.MISSING }}", secretData)
+	assert.ErrorIs(t, err, errs.ErrUndefinedSecret)
+	assert.Contains(t, err.Error(), "MISSING")
+
+	assert.Equal(t, []string{"NPM_TOKEN", "UNUSED"}, secretParser.UnusedSecrets(secretData))
+}
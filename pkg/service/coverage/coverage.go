@@ -15,6 +15,7 @@ import (
 	"path"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	"github.com/LambdaTest/synapse/config"
 	"github.com/LambdaTest/synapse/pkg/core"
@@ -23,6 +24,7 @@ import (
 
 	"github.com/LambdaTest/synapse/pkg/fileutils"
 	"github.com/LambdaTest/synapse/pkg/lumber"
+	"github.com/LambdaTest/synapse/pkg/utils"
 )
 
 const (
@@ -41,6 +43,8 @@ type codeCoverageService struct {
 	zstd                 core.ZstdCompressor
 	httpClient           http.Client
 	endpoint             string
+	tempDir              string
+	uploadConcurrency    int
 }
 
 // New returns a new instance of CoverageService
@@ -56,6 +60,10 @@ func New(execManager core.ExecutionManager,
 	if _, err := os.Stat(global.CodeCoveragParentDir); os.IsNotExist(err) {
 		return nil, errors.New("coverage directory not mounted")
 	}
+	uploadConcurrency := cfg.CoverageUploadWorkers
+	if uploadConcurrency < 1 {
+		uploadConcurrency = 1
+	}
 	return &codeCoverageService{
 		logger:               logger,
 		execManager:          execManager,
@@ -63,6 +71,8 @@ func New(execManager core.ExecutionManager,
 		zstd:                 zstd,
 		codeCoveragParentDir: global.CodeCoveragParentDir,
 		endpoint:             global.NeuronHost + "/coverage",
+		tempDir:              cfg.TempDir,
+		uploadConcurrency:    uploadConcurrency,
 		httpClient: http.Client{
 			Timeout: global.DefaultHTTPTimeout,
 		}}, nil
@@ -100,10 +110,11 @@ func (c *codeCoverageService) mergeCodeCoverageFiles(ctx context.Context, commit
 	return c.execManager.ExecuteInternalCommands(ctx, core.CoverageMerge, args, "", nil, nil)
 }
 
-// MergeAndUpload compress the file and upload in azure blob
+// MergeAndUpload merges the coverage of each commit and uploads it to azure blob. The commits are merged
+// one after the other as each one builds on its parent, the uploads run concurrently in the background.
+// The coverage data is sent only if all the uploads succeed.
 func (c *codeCoverageService) MergeAndUpload(ctx context.Context, payload *core.Payload) error {
 	var parentCommitDir, repoDir string
-	var g errgroup.Group
 	// change variable name
 	repoDir = filepath.Join(c.codeCoveragParentDir, payload.OrgID, payload.RepoID)
 	repoBlobPath := path.Join(payload.GitProvider, payload.OrgID, payload.RepoID)
@@ -119,9 +130,15 @@ func (c *codeCoverageService) MergeAndUpload(ctx context.Context, payload *core.
 		}
 		parentCommitDir = filepath.Join(repoDir, coverage.ParentCommit)
 	}
-	coveragePayload := make([]coverageData, 0, len(payload.Commits))
+	coveragePayload := make([]coverageData, len(payload.Commits))
+	uploadErrs := make([]error, len(payload.Commits))
 
-	for _, commit := range payload.Commits {
+	var wg sync.WaitGroup
+	// wait for the running uploads on merge failures as well
+	defer wg.Wait()
+	sem := make(chan struct{}, c.uploadConcurrency)
+
+	for i, commit := range payload.Commits {
 		commitDir := filepath.Join(repoDir, commit.Sha)
 		c.logger.Debugf("commit directory %s", commitDir)
 
@@ -151,49 +168,88 @@ func (c *codeCoverageService) MergeAndUpload(ctx context.Context, payload *core.
 			c.logger.Errorf("failed to merge coverage files %v", err)
 			return err
 		}
-		c.logger.Debugf("compressed file name %v", compressedFileName)
-
-		g.Go(func() error {
-			if err := c.zstd.Compress(ctx, compressedFileName, false, repoDir, commit.Sha); err != nil {
-				c.logger.Errorf("failed to compress coverage files %v", err)
-				return err
-			}
-			_, err := c.uploadFile(ctx, repoBlobPath, compressedFileName, commit.Sha)
-			if err != nil {
-				return err
-			}
-			return nil
-		})
-
-		var blobURL string
-		g.Go(func() error {
-			blobURL, err = c.uploadFile(ctx, repoBlobPath, filepath.Join(commitDir, mergedcoverageJSON), commit.Sha)
-			if err != nil {
+		if len(manifestPayload.CoverageExclude) > 0 {
+			if err := c.excludeCoverageFiles(filepath.Join(commitDir, mergedcoverageJSON), manifestPayload.CoverageExclude); err != nil {
+				c.logger.Errorf("failed to exclude files from coverage %v", err)
 				return err
 			}
-			return nil
-		})
+		}
 
-		var totalCoverage json.RawMessage
-		g.Go(func() error {
-			totalCoverage, err = c.getTotalCoverage(filepath.Join(commitDir, mergedcoverageJSON))
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int, commitID, commitDir string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			data, err := c.uploadCommitCoverage(ctx, repoDir, repoBlobPath, commitID, commitDir)
 			if err != nil {
-				return err
+				c.logger.Errorf("failed to upload coverage of commit %s to azure blob %v", commitID, err)
+				uploadErrs[i] = err
+				return
 			}
-			return nil
-		})
-		if err = g.Wait(); err != nil {
-			c.logger.Errorf("failed to upload files to azure blob %v", err)
-			return err
-		}
-		blobURL = strings.TrimSuffix(blobURL, fmt.Sprintf("/%s", mergedcoverageJSON))
-		coveragePayload = append(coveragePayload, coverageData{BuildID: payload.BuildID, RepoID: payload.RepoID, CommitID: commit.Sha, BlobLink: blobURL, TotalCoverage: totalCoverage})
+			data.BuildID = payload.BuildID
+			data.RepoID = payload.RepoID
+			coveragePayload[i] = data
+		}(i, commit.Sha, commitDir)
 		//current commit dir becomes parent for next commit
 		parentCommitDir = commitDir
 	}
+	wg.Wait()
+
+	var failed []string
+	var uploadErr error
+	for i, err := range uploadErrs {
+		if err != nil {
+			failed = append(failed, payload.Commits[i].Sha)
+			uploadErr = err
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to upload coverage of commits %s: %w", strings.Join(failed, ", "), uploadErr)
+	}
 	return c.sendCoverageData(coveragePayload)
 }
 
+// uploadCommitCoverage compresses and uploads the coverage files of the commit along with the merged report.
+func (c *codeCoverageService) uploadCommitCoverage(ctx context.Context, repoDir, repoBlobPath, commitID, commitDir string) (coverageData, error) {
+	var g errgroup.Group
+	// each commit is compressed to its own file as the uploads run concurrently
+	compressedDir := filepath.Join(c.tempDir, commitID)
+	if err := os.MkdirAll(compressedDir, 0755); err != nil {
+		return coverageData{}, err
+	}
+	defer os.RemoveAll(compressedDir)
+	compressedFilePath := filepath.Join(compressedDir, compressedFileName)
+	c.logger.Debugf("compressed file name %v", compressedFilePath)
+
+	g.Go(func() error {
+		if err := c.zstd.Compress(ctx, compressedFilePath, false, repoDir, commitID); err != nil {
+			c.logger.Errorf("failed to compress coverage files %v", err)
+			return err
+		}
+		_, err := c.uploadFile(ctx, repoBlobPath, compressedFilePath, commitID)
+		return err
+	})
+
+	var blobURL string
+	g.Go(func() error {
+		var err error
+		blobURL, err = c.uploadFile(ctx, repoBlobPath, filepath.Join(commitDir, mergedcoverageJSON), commitID)
+		return err
+	})
+
+	var totalCoverage json.RawMessage
+	g.Go(func() error {
+		var err error
+		totalCoverage, err = c.getTotalCoverage(filepath.Join(commitDir, mergedcoverageJSON))
+		return err
+	})
+	if err := g.Wait(); err != nil {
+		return coverageData{}, err
+	}
+	blobURL = strings.TrimSuffix(blobURL, fmt.Sprintf("/%s", mergedcoverageJSON))
+	return coverageData{CommitID: commitID, BlobLink: blobURL, TotalCoverage: totalCoverage}, nil
+}
+
 func (c *codeCoverageService) uploadFile(ctx context.Context, blobPath, filename, commitID string) (blobURL string, err error) {
 	file, err := os.Open(filename)
 	if err != nil {
@@ -260,12 +316,12 @@ func (c *codeCoverageService) downloadAndDecompressParentCommitDir(ctx context.C
 	}
 
 	// decompress the file in temp directory as we cannot decompress inside azure file volume
-	if err := c.zstd.Decompress(ctx, parentCommitFilePath, false, os.TempDir()); err != nil {
+	if err := c.zstd.Decompress(ctx, parentCommitFilePath, false, c.tempDir); err != nil {
 		c.logger.Errorf("failed to decompress parent commit directory %v", err)
 		return err
 	}
 
-	srcPath := filepath.Join(os.TempDir(), coverage.ParentCommit)
+	srcPath := filepath.Join(c.tempDir, coverage.ParentCommit)
 	destPath := filepath.Join(repoDir, coverage.ParentCommit)
 	// copy the coverage directories to shared volume,
 	// chmod is not allowed inside azure file volume so that is skipped Ref: https://stackoverflow.com/questions/58301985/permissions-on-azure-file
@@ -329,6 +385,7 @@ func (c *codeCoverageService) getParentCommitCoverageDir(repoID, commitID string
 		c.logger.Errorf("failed to create new request %v", err)
 		return coverage, err
 	}
+	utils.AddNeuronHeaders(req)
 
 	resp, err := c.httpClient.Do(req)
 
@@ -367,6 +424,7 @@ func (c *codeCoverageService) sendCoverageData(payload []coverageData) error {
 		c.logger.Errorf("failed to create new request %v", err)
 		return err
 	}
+	utils.AddNeuronHeaders(req)
 
 	resp, err := c.httpClient.Do(req)
 
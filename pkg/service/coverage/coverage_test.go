@@ -0,0 +1,190 @@
+package coverage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/LambdaTest/synapse/pkg/core"
+	"github.com/LambdaTest/synapse/pkg/lumber"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeExecutionManager skips the merge, the merged report is created by the test
+type fakeExecutionManager struct{}
+
+func (f *fakeExecutionManager) ExecuteUserCommands(ctx context.Context, commandType core.CommandType, payload *core.Payload, runConfig *core.Run, secretData map[string]string) error {
+	return nil
+}
+
+func (f *fakeExecutionManager) ExecuteInternalCommands(ctx context.Context, commandType core.CommandType, commands []string, cwd string, envMap, secretData map[string]string) error {
+	return nil
+}
+
+func (f *fakeExecutionManager) GetEnvVariables(envMap, secretData map[string]string) ([]string, error) {
+	return nil, nil
+}
+
+func (f *fakeExecutionManager) Deprioritize(cmd *exec.Cmd) error {
+	return nil
+}
+
+func (f *fakeExecutionManager) StartProcessGroup(ctx context.Context, cmd *exec.Cmd) (func() error, error) {
+	return nil, nil
+}
+
+func (f *fakeExecutionManager) BlockNetwork() (core.NetworkGuard, error) {
+	return nil, nil
+}
+
+func (f *fakeExecutionManager) StoreCommandLogs(ctx context.Context, blobPath string, reader io.Reader) <-chan error {
+	errChan := make(chan error)
+	close(errChan)
+	return errChan
+}
+
+// fakeZstd writes the names of the compressed files instead of compressing them
+type fakeZstd struct{}
+
+func (f *fakeZstd) Compress(ctx context.Context, compressedFileName string, preservePath bool, workingDirectory string, filesToCompress ...string) error {
+	return ioutil.WriteFile(compressedFileName, []byte(strings.Join(filesToCompress, "\n")), 0644)
+}
+
+func (f *fakeZstd) Decompress(ctx context.Context, filePath string, preservePath bool, workingDirectory string, filesToDecompress ...string) error {
+	return nil
+}
+
+// fakeAzureClient records the uploaded blobs along with the maximum number of concurrent uploads
+type fakeAzureClient struct {
+	core.AzureClient
+	failCommit string
+
+	mu          sync.Mutex
+	inFlight    int
+	maxInFlight int
+	blobs       map[string]string
+}
+
+func (f *fakeAzureClient) Create(ctx context.Context, path string, reader io.Reader, mimeType string) (string, error) {
+	f.mu.Lock()
+	f.inFlight++
+	if f.inFlight > f.maxInFlight {
+		f.maxInFlight = f.inFlight
+	}
+	f.mu.Unlock()
+	defer func() {
+		f.mu.Lock()
+		f.inFlight--
+		f.mu.Unlock()
+	}()
+
+	content, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return "", err
+	}
+	time.Sleep(20 * time.Millisecond)
+	if f.failCommit != "" && strings.Contains(path, f.failCommit) {
+		return "", errors.New("upload failed")
+	}
+	f.mu.Lock()
+	f.blobs[path] = string(content)
+	f.mu.Unlock()
+	return "https://blob.example.com/" + path, nil
+}
+
+func TestMergeAndUpload(t *testing.T) {
+	logger, err := lumber.NewLogger(lumber.LoggingConfig{EnableConsole: true}, true, lumber.InstanceZapLogger)
+	if err != nil {
+		log.Fatalf("Could not instantiate logger %s", err.Error())
+	}
+
+	tests := []struct {
+		name       string
+		failCommit string
+		wantErr    bool
+	}{
+		{"all uploads succeed", "", false},
+		{"partial upload failure", "sha2", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parentDir := t.TempDir()
+			payload := &core.Payload{OrgID: "org", RepoID: "repo", BuildID: "build", GitProvider: "github"}
+			for i := 1; i <= 4; i++ {
+				sha := fmt.Sprintf("sha%d", i)
+				payload.Commits = append(payload.Commits, core.CommitChangeList{Sha: sha})
+				commitDir := filepath.Join(parentDir, "org", "repo", sha)
+				if err := os.MkdirAll(commitDir, 0755); err != nil {
+					t.Fatalf("failed to create dir: %v", err)
+				}
+				files := map[string]string{
+					mainfestJSONFileName: `{"all_files_executed": true}`,
+					coverageJSONFileName: `{}`,
+					mergedcoverageJSON:   fmt.Sprintf(`{"total": {"lines": {"pct": %d}}}`, i),
+				}
+				for name, content := range files {
+					if err := ioutil.WriteFile(filepath.Join(commitDir, name), []byte(content), 0644); err != nil {
+						t.Fatalf("failed to write file: %v", err)
+					}
+				}
+			}
+
+			var received []coverageData
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := ioutil.ReadAll(r.Body)
+				assert.Nil(t, json.Unmarshal(body, &received))
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			azureClient := &fakeAzureClient{failCommit: tt.failCommit, blobs: make(map[string]string)}
+			c := &codeCoverageService{
+				logger:               logger,
+				execManager:          &fakeExecutionManager{},
+				azureClient:          azureClient,
+				zstd:                 &fakeZstd{},
+				codeCoveragParentDir: parentDir,
+				endpoint:             server.URL,
+				tempDir:              t.TempDir(),
+				uploadConcurrency:    2,
+			}
+			err := c.MergeAndUpload(context.TODO(), payload)
+			// each commit uploads 2 files, more than 2 uploads in flight means the commits are uploaded concurrently
+			assert.Greater(t, azureClient.maxInFlight, 2)
+			assert.LessOrEqual(t, azureClient.maxInFlight, 4)
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), tt.failCommit) {
+					t.Errorf("MergeAndUpload() error = %v, want failure of commit %s", err, tt.failCommit)
+				}
+				assert.Nil(t, received, "coverage data must not be sent on upload failures")
+				return
+			}
+			if err != nil {
+				t.Fatalf("MergeAndUpload() error = %v", err)
+			}
+			assert.Len(t, azureClient.blobs, 8)
+			assert.Equal(t, "sha3", azureClient.blobs["github/org/repo/sha3/"+compressedFileName])
+			if assert.Len(t, received, 4) {
+				for i, data := range received {
+					sha := fmt.Sprintf("sha%d", i+1)
+					assert.Equal(t, sha, data.CommitID)
+					assert.Equal(t, "https://blob.example.com/github/org/repo/"+sha, data.BlobLink)
+					assert.JSONEq(t, fmt.Sprintf(`{"lines": {"pct": %d}}`, i+1), string(data.TotalCoverage))
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package coverage
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/LambdaTest/synapse/pkg/global"
+)
+
+const totalKey = "total"
+
+// coverageMetric is the summary of a single metric, eg. lines, of the istanbul json-summary report
+type coverageMetric struct {
+	Total   int             `json:"total"`
+	Covered int             `json:"covered"`
+	Skipped int             `json:"skipped"`
+	Pct     json.RawMessage `json:"pct"`
+}
+
+// WriteCoverageExclude adds the exclusion patterns of the tas configuration to the coverage manifest the
+// runner wrote in the coverage directory of the commit, so that the coverage job filters the merged report.
+// The other fields of the manifest are kept as they are.
+func WriteCoverageExclude(commitDir string, patterns []string) error {
+	manifestPath := filepath.Join(commitDir, mainfestJSONFileName)
+	manifest := make(map[string]json.RawMessage)
+	body, err := ioutil.ReadFile(manifestPath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err == nil {
+		if err := json.Unmarshal(body, &manifest); err != nil {
+			return err
+		}
+	}
+	if manifest["coverage_exclude"], err = json.Marshal(patterns); err != nil {
+		return err
+	}
+	if body, err = json.Marshal(manifest); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(manifestPath, body, 0644)
+}
+
+// excludeCoverageFiles removes the files matching any of the glob patterns from the merged
+// coverage summary at path and recomputes the total coverage of the remaining files.
+func (c *codeCoverageService) excludeCoverageFiles(path string, patterns []string) error {
+	matchers, err := compileGlobs(patterns)
+	if err != nil {
+		return err
+	}
+	body, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var summary map[string]map[string]coverageMetric
+	if err = json.Unmarshal(body, &summary); err != nil {
+		return err
+	}
+
+	totals := make(map[string]coverageMetric, len(summary[totalKey]))
+	for metric := range summary[totalKey] {
+		totals[metric] = coverageMetric{}
+	}
+	for file, metrics := range summary {
+		if file == totalKey {
+			continue
+		}
+		relPath := strings.TrimPrefix(filepath.ToSlash(file), global.RepoDir+"/")
+		if matchAny(matchers, relPath) {
+			c.logger.Debugf("excluding %s from coverage", file)
+			delete(summary, file)
+			continue
+		}
+		for metric, m := range metrics {
+			total := totals[metric]
+			total.Total += m.Total
+			total.Covered += m.Covered
+			total.Skipped += m.Skipped
+			totals[metric] = total
+		}
+	}
+	for metric, total := range totals {
+		total.Pct = percentage(total.Covered, total.Total)
+		totals[metric] = total
+	}
+	summary[totalKey] = totals
+
+	body, err = json.Marshal(summary)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, body, 0644)
+}
+
+// percentage returns the covered percentage rounded to two decimals, as istanbul does.
+// Nothing to cover counts as fully covered.
+func percentage(covered, total int) json.RawMessage {
+	pct := 100.0
+	if total > 0 {
+		pct = math.Floor(float64(covered)*10000/float64(total)) / 100
+	}
+	raw, _ := json.Marshal(pct)
+	return raw
+}
+
+// compileGlobs converts the glob patterns to regular expressions. Besides the usual wildcards
+// "**" matches any number of directories.
+func compileGlobs(patterns []string) ([]*regexp.Regexp, error) {
+	matchers := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		pattern = strings.TrimPrefix(filepath.ToSlash(pattern), "./")
+		var expr strings.Builder
+		expr.WriteString("^")
+		for i := 0; i < len(pattern); i++ {
+			switch ch := pattern[i]; {
+			case strings.HasPrefix(pattern[i:], "**/"):
+				expr.WriteString("(.*/)?")
+				i += 2
+			case strings.HasPrefix(pattern[i:], "**"):
+				expr.WriteString(".*")
+				i++
+			case ch == '*':
+				expr.WriteString("[^/]*")
+			case ch == '?':
+				expr.WriteString("[^/]")
+			default:
+				expr.WriteString(regexp.QuoteMeta(string(ch)))
+			}
+		}
+		expr.WriteString("$")
+		matcher, err := regexp.Compile(expr.String())
+		if err != nil {
+			return nil, err
+		}
+		matchers = append(matchers, matcher)
+	}
+	return matchers, nil
+}
+
+func matchAny(matchers []*regexp.Regexp, path string) bool {
+	for _, matcher := range matchers {
+		if matcher.MatchString(path) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,161 @@
+package coverage
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/LambdaTest/synapse/pkg/core"
+	"github.com/LambdaTest/synapse/pkg/lumber"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+func TestExcludeCoverageFiles(t *testing.T) {
+	logger, err := lumber.NewLogger(lumber.LoggingConfig{EnableConsole: true}, true, lumber.InstanceZapLogger)
+	if err != nil {
+		log.Fatalf("Could not instantiate logger %s", err.Error())
+	}
+	c := &codeCoverageService{logger: logger}
+
+	summary := `{
+		"total": {"lines": {"total": 40, "covered": 25, "skipped": 0, "pct": 62.5}, "branches": {"total": 6, "covered": 3, "skipped": 0, "pct": 50}},
+		"/home/nucleus/repo/src/index.js": {"lines": {"total": 10, "covered": 9, "skipped": 0, "pct": 90}, "branches": {"total": 4, "covered": 3, "skipped": 0, "pct": 75}},
+		"/home/nucleus/repo/src/math.js": {"lines": {"total": 3, "covered": 1, "skipped": 1, "pct": 33.33}, "branches": {"total": 0, "covered": 0, "skipped": 0, "pct": 100}},
+		"/home/nucleus/repo/src/generated/api.js": {"lines": {"total": 20, "covered": 10, "skipped": 0, "pct": 50}, "branches": {"total": 2, "covered": 0, "skipped": 0, "pct": 0}},
+		"/home/nucleus/repo/lib/vendor/lodash.js": {"lines": {"total": 7, "covered": 5, "skipped": 0, "pct": 71.42}, "branches": {"total": 0, "covered": 0, "skipped": 0, "pct": "Unknown"}}
+	}`
+	path := filepath.Join(t.TempDir(), mergedcoverageJSON)
+	if err := ioutil.WriteFile(path, []byte(summary), 0644); err != nil {
+		t.Fatalf("failed to write summary: %v", err)
+	}
+
+	err = c.excludeCoverageFiles(path, []string{"./src/generated/**", "**/vendor/*.js"})
+	if err != nil {
+		t.Fatalf("excludeCoverageFiles() error = %v", err)
+	}
+	body, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read summary: %v", err)
+	}
+	var got map[string]map[string]coverageMetric
+	if err := json.Unmarshal(body, &got); err != nil {
+		t.Fatalf("failed to unmarshal summary: %v", err)
+	}
+	assert.NotContains(t, got, "/home/nucleus/repo/src/generated/api.js")
+	assert.NotContains(t, got, "/home/nucleus/repo/lib/vendor/lodash.js")
+	assert.Contains(t, got, "/home/nucleus/repo/src/index.js")
+	assert.Contains(t, got, "/home/nucleus/repo/src/math.js")
+
+	lines := got["total"]["lines"]
+	assert.Equal(t, 13, lines.Total)
+	assert.Equal(t, 10, lines.Covered)
+	assert.Equal(t, 1, lines.Skipped)
+	assert.JSONEq(t, "76.92", string(lines.Pct))
+	branches := got["total"]["branches"]
+	assert.Equal(t, 4, branches.Total)
+	assert.Equal(t, 3, branches.Covered)
+	assert.JSONEq(t, "75", string(branches.Pct))
+
+	// the total coverage reported to neuron is read from the filtered summary
+	total, err := c.getTotalCoverage(path)
+	assert.Nil(t, err)
+	assert.Contains(t, string(total), `"pct":76.92`)
+}
+
+func TestCompileGlobs(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"src/generated/**", "src/generated/api.js", true},
+		{"src/generated/**", "src/generated/v1/api.js", true},
+		{"src/generated/**", "src/index.js", false},
+		{"**/*.gen.js", "api.gen.js", true},
+		{"**/*.gen.js", "src/deep/api.gen.js", true},
+		{"src/*.js", "src/nested/index.js", false},
+		{"src/?.js", "src/a.js", true},
+		{"src/a.js", "src/a_js", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.path, func(t *testing.T) {
+			matchers, err := compileGlobs([]string{tt.pattern})
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, matchAny(matchers, tt.path))
+		})
+	}
+}
+
+func TestCoverageExcludeFromConfig(t *testing.T) {
+	logger, err := lumber.NewLogger(lumber.LoggingConfig{EnableConsole: true}, true, lumber.InstanceZapLogger)
+	if err != nil {
+		log.Fatalf("Could not instantiate logger %s", err.Error())
+	}
+	var tasConfig core.TASConfig
+	tasYaml := "framework: jest\ncoverageExclude:\n  - src/generated/**\n"
+	if err := yaml.Unmarshal([]byte(tasYaml), &tasConfig); err != nil {
+		t.Fatalf("failed to parse tas yaml: %v", err)
+	}
+
+	parentDir := t.TempDir()
+	commitDir := filepath.Join(parentDir, "org", "repo", "sha1")
+	if err := os.MkdirAll(commitDir, 0755); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	// the files written by the runner, the merged report is left by the fake merge command
+	files := map[string]string{
+		mainfestJSONFileName: `{"all_files_executed": true, "removed_files": []}`,
+		coverageJSONFileName: `{}`,
+		mergedcoverageJSON: `{
+			"total": {"lines": {"total": 30, "covered": 19, "skipped": 0, "pct": 63.33}},
+			"/home/nucleus/repo/src/index.js": {"lines": {"total": 10, "covered": 9, "skipped": 0, "pct": 90}},
+			"/home/nucleus/repo/src/generated/api.js": {"lines": {"total": 20, "covered": 10, "skipped": 0, "pct": 50}}
+		}`,
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(commitDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+	}
+	if err := WriteCoverageExclude(commitDir, tasConfig.CoverageExclude); err != nil {
+		t.Fatalf("WriteCoverageExclude() error = %v", err)
+	}
+	manifest, err := ioutil.ReadFile(filepath.Join(commitDir, mainfestJSONFileName))
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"all_files_executed": true, "removed_files": [], "coverage_exclude": ["src/generated/**"]}`, string(manifest))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	c := &codeCoverageService{
+		logger:               logger,
+		execManager:          &fakeExecutionManager{},
+		azureClient:          &fakeAzureClient{blobs: make(map[string]string)},
+		zstd:                 &fakeZstd{},
+		codeCoveragParentDir: parentDir,
+		endpoint:             server.URL,
+		tempDir:              t.TempDir(),
+		uploadConcurrency:    1,
+	}
+	payload := &core.Payload{OrgID: "org", RepoID: "repo", BuildID: "build", GitProvider: "github",
+		Commits: []core.CommitChangeList{{Sha: "sha1"}}}
+	if err := c.MergeAndUpload(context.TODO(), payload); err != nil {
+		t.Fatalf("MergeAndUpload() error = %v", err)
+	}
+
+	body, err := ioutil.ReadFile(filepath.Join(commitDir, mergedcoverageJSON))
+	assert.Nil(t, err)
+	var got map[string]map[string]coverageMetric
+	assert.Nil(t, json.Unmarshal(body, &got))
+	assert.NotContains(t, got, "/home/nucleus/repo/src/generated/api.js")
+	assert.Contains(t, got, "/home/nucleus/repo/src/index.js")
+	assert.Equal(t, 10, got["total"]["lines"].Total)
+}
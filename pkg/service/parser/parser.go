@@ -12,6 +12,7 @@ import (
 	"github.com/LambdaTest/synapse/pkg/core"
 	"github.com/LambdaTest/synapse/pkg/global"
 	"github.com/LambdaTest/synapse/pkg/lumber"
+	"github.com/LambdaTest/synapse/pkg/utils"
 )
 
 // Parser represents the code parser object
@@ -97,6 +98,7 @@ func (p *Parser) sendParserResponse(payload *core.ParserResponse) error {
 		p.logger.Errorf("failed to create new request %v", err)
 		return err
 	}
+	utils.AddNeuronHeaders(req)
 
 	resp, err := p.httpClient.Do(req)
 
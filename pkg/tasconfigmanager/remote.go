@@ -0,0 +1,75 @@
+package tasconfigmanager
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/LambdaTest/synapse/pkg/core"
+	"github.com/LambdaTest/synapse/pkg/errs"
+	"github.com/LambdaTest/synapse/pkg/fileutils"
+	"github.com/LambdaTest/synapse/pkg/global"
+	"github.com/LambdaTest/synapse/pkg/urlmanager"
+)
+
+// LoadRemoteConfig downloads only the tas configuration file at path of the given commit from the git provider
+// and validates it, without cloning the repo. The defaults depending on the repo content, like the cache key,
+// are not resolved.
+func (tc *TASConfigManager) LoadRemoteConfig(ctx context.Context,
+	gitProvider, repoSlug, commitID, path, cloneToken string,
+	eventType core.EventType) (*core.TASConfig, error) {
+	if filepath.IsAbs(path) || !fileutils.IsWithin(".", filepath.Clean(path)) {
+		return nil, fmt.Errorf("configuration file path %s is outside of the repo", path)
+	}
+	downloadURL, err := urlmanager.GetDownloadURL(gitProvider, repoSlug, commitID, path)
+	if err != nil {
+		tc.logger.Errorf("failed to get download url for provider %s, error %v", gitProvider, err)
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, downloadURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if cloneToken != "" {
+		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", cloneToken))
+	}
+	client := http.Client{Timeout: global.DefaultHTTPTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		tc.logger.Errorf("error while downloading configuration file %s, error %v", path, err)
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("Configuration file not found at path: %s", path)
+	}
+	if resp.StatusCode != http.StatusOK {
+		tc.logger.Errorf("non 200 status while downloading configuration file %s, status %d", path, resp.StatusCode)
+		return nil, errs.ErrApiStatus
+	}
+
+	repoDir, err := ioutil.TempDir(tc.tempDir, "tas-config-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(repoDir)
+	configPath := filepath.Join(repoDir, path)
+	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+		return nil, err
+	}
+	out, err := os.Create(configPath)
+	if err != nil {
+		return nil, err
+	}
+	_, err = io.Copy(out, resp.Body)
+	out.Close()
+	if err != nil {
+		return nil, err
+	}
+	// only the configuration file exists locally, so the defaults derived from the repo are skipped
+	return tc.LoadConfigFromDir(ctx, repoDir, path, eventType, true)
+}
@@ -0,0 +1,82 @@
+package tasconfigmanager
+
+import (
+	"context"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/LambdaTest/synapse/config"
+	"github.com/LambdaTest/synapse/pkg/core"
+	"github.com/LambdaTest/synapse/pkg/global"
+	"github.com/LambdaTest/synapse/pkg/lumber"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadRemoteConfig(t *testing.T) {
+	logger, err := lumber.NewLogger(lumber.LoggingConfig{EnableConsole: true}, true, lumber.InstanceZapLogger)
+	if err != nil {
+		log.Fatalf("Could not instantiate logger %s", err.Error())
+	}
+	valid, err := ioutil.ReadFile("testdata/.tas.yml")
+	if err != nil {
+		t.Fatalf("failed to read config: %v", err)
+	}
+	files := map[string]string{
+		"/org/repo/abc/.tas.yml":        string(valid),
+		"/org/repo/abc/config/.tas.yml": "framework: karma\n",
+		"/org/repo/abc/broken/.tas.yml": "framework: [",
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		content, ok := files[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(content)) // nolint:errcheck
+	}))
+	defer server.Close()
+
+	rawContentURL := global.RawContentURLMap[core.GitHub]
+	global.RawContentURLMap[core.GitHub] = server.URL
+	defer func() { global.RawContentURLMap[core.GitHub] = rawContentURL }()
+
+	tests := []struct {
+		name    string
+		path    string
+		token   string
+		wantErr string
+	}{
+		{"valid config", ".tas.yml", "token", ""},
+		{"invalid values", "config/.tas.yml", "token", "Invalid values provided"},
+		{"invalid format", "broken/.tas.yml", "token", "Invalid format of configuration file"},
+		{"missing config", "missing/.tas.yml", "token", "Configuration file not found at path: missing/.tas.yml"},
+		{"unauthorized", ".tas.yml", "", "non OK status"},
+		{"parent path", "../.tas.yml", "token", "outside of the repo"},
+		{"nested parent path", "config/../../.tas.yml", "token", "outside of the repo"},
+		{"absolute path", "/etc/.tas.yml", "token", "outside of the repo"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tcm := NewTASConfigManager(&config.NucleusConfig{TempDir: t.TempDir()}, logger)
+			tasConfig, err := tcm.LoadRemoteConfig(context.TODO(), core.GitHub, "org/repo", "abc", tt.path, tt.token, core.EventPush)
+			if tt.wantErr != "" {
+				if assert.NotNil(t, err) {
+					assert.Contains(t, err.Error(), tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadRemoteConfig() error = %v", err)
+			}
+			assert.Equal(t, "mocha", tasConfig.Framework)
+			assert.False(t, tasConfig.SmartRun)
+		})
+	}
+}
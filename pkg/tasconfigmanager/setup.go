@@ -3,6 +3,7 @@ package tasconfigmanager
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
@@ -12,7 +13,9 @@ import (
 
 	"github.com/LambdaTest/synapse/pkg/global"
 
+	"github.com/LambdaTest/synapse/config"
 	"github.com/LambdaTest/synapse/pkg/core"
+	"github.com/LambdaTest/synapse/pkg/errs"
 	"github.com/LambdaTest/synapse/pkg/lumber"
 	"github.com/LambdaTest/synapse/pkg/utils"
 	"github.com/go-playground/locales/en"
@@ -33,14 +36,20 @@ const (
 
 // TASConfigManager represents an instance of TASConfigManager instance
 type TASConfigManager struct {
-	logger     lumber.Logger
+	logger lumber.Logger
+	// framework overrides the framework of the loaded configuration, if set
+	framework string
+	// defaultTier is the tier of the configurations which do not set one
+	defaultTier core.Tier
+	// tempDir is the directory under which the remote configurations are downloaded
+	tempDir    string
 	uni        *ut.UniversalTranslator
 	validate   *validator.Validate
 	translator ut.Translator
 }
 
 // NewTASConfigManager creates and returns a new TASConfigManager instance
-func NewTASConfigManager(logger lumber.Logger) *TASConfigManager {
+func NewTASConfigManager(cfg *config.NucleusConfig, logger lumber.Logger) *TASConfigManager {
 	en := en.New()
 	uni := ut.New(en, en)
 	trans, _ := uni.GetTranslator("en")
@@ -48,7 +57,21 @@ func NewTASConfigManager(logger lumber.Logger) *TASConfigManager {
 	en_translations.RegisterDefaultTranslations(validate, trans)
 	configureValidator(validate, trans)
 
-	return &TASConfigManager{logger: logger, uni: uni, validate: validate, translator: trans}
+	defaultTier := core.Tier(cfg.DefaultTier)
+	if defaultTier == "" {
+		defaultTier = core.Small
+	}
+
+	return &TASConfigManager{logger: logger, framework: cfg.Framework, defaultTier: defaultTier, tempDir: cfg.TempDir, uni: uni, validate: validate, translator: trans}
+}
+
+// knownTier reports whether tier is one of the tiers which can be set in the configuration file
+func knownTier(tier core.Tier) bool {
+	switch tier {
+	case core.XSmall, core.Small, core.Medium, core.Large, core.XLarge:
+		return true
+	}
+	return false
 }
 
 // LoadConfig used for loading and validating the  tas configuration values provided by user
@@ -56,8 +79,18 @@ func (tc *TASConfigManager) LoadConfig(ctx context.Context,
 	path string,
 	eventType core.EventType,
 	parseMode bool) (*core.TASConfig, error) {
+	return tc.LoadConfigFromDir(ctx, global.RepoDir, path, eventType, parseMode)
+}
 
-	yamlFile, err := ioutil.ReadFile(fmt.Sprintf("%s/%s", global.RepoDir, path))
+// LoadConfigFromDir loads and validates the tas configuration at path relative to repoDir
+// and resolves all the default values
+func (tc *TASConfigManager) LoadConfigFromDir(ctx context.Context,
+	repoDir string,
+	path string,
+	eventType core.EventType,
+	parseMode bool) (*core.TASConfig, error) {
+
+	yamlFile, err := ioutil.ReadFile(fmt.Sprintf("%s/%s", repoDir, path))
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
 			return nil, fmt.Errorf("Configuration file not found at path: %s", path)
@@ -66,7 +99,11 @@ func (tc *TASConfigManager) LoadConfig(ctx context.Context,
 		return nil, fmt.Errorf("Error while reading configuration file at path: %s", path)
 	}
 
-	tasConfig := &core.TASConfig{SmartRun: true, Tier: core.Small}
+	if !knownTier(tc.defaultTier) {
+		return nil, fmt.Errorf("%w: %s", errs.ErrUnknownTier, tc.defaultTier)
+	}
+
+	tasConfig := &core.TASConfig{SmartRun: true, Tier: tc.defaultTier}
 
 	err = yaml.Unmarshal(yamlFile, tasConfig)
 	if err != nil {
@@ -90,8 +127,12 @@ func (tc *TASConfigManager) LoadConfig(ctx context.Context,
 
 	}
 
+	if tasConfig.DiscoveryWarmup != "" && strings.TrimSpace(tasConfig.DiscoveryWarmup) == "" {
+		return nil, errors.New("`discoveryWarmup` must be a command")
+	}
+
 	if !parseMode && tasConfig.Cache == nil {
-		checksum, err := utils.ComputeChecksum(fmt.Sprintf("%s/%s", global.RepoDir, packageJSON))
+		checksum, err := utils.ComputeChecksum(fmt.Sprintf("%s/%s", repoDir, packageJSON))
 		if err != nil {
 			tc.logger.Errorf("Error while computing checksum, error %v", err)
 			return nil, err
@@ -106,6 +147,15 @@ func (tc *TASConfigManager) LoadConfig(ctx context.Context,
 		tasConfig.CoverageThreshold = new(core.CoverageThreshold)
 	}
 
+	if tc.framework != "" {
+		if _, ok := global.FrameworkRunnerMap[tc.framework]; !ok {
+			return nil, fmt.Errorf("%w: %s", errs.ErrUnsupportedFramework, tc.framework)
+		}
+		tc.logger.Warnf("Framework override is active, running with framework %s instead of %s from configuration file",
+			tc.framework, tasConfig.Framework)
+		tasConfig.Framework = tc.framework
+	}
+
 	switch eventType {
 	case core.EventPullRequest:
 		if tasConfig.Premerge == nil {
@@ -120,6 +170,54 @@ func (tc *TASConfigManager) LoadConfig(ctx context.Context,
 
 }
 
+// MarshalConfig serializes the resolved tas configuration in the given format (yaml or json)
+func MarshalConfig(tasConfig *core.TASConfig, format string) ([]byte, error) {
+	raw, err := yaml.Marshal(tasConfig)
+	if err != nil {
+		return nil, err
+	}
+	doc := make(map[string]interface{})
+	if err := yaml.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+	// semver.Version has no yaml marshaller, print it in its canonical form
+	if tasConfig.NodeVersion != nil {
+		doc["nodeVersion"] = tasConfig.NodeVersion.String()
+	}
+	switch format {
+	case "yaml":
+		return yaml.Marshal(doc)
+	case "json":
+		return json.MarshalIndent(toJSONCompatible(doc), "", "  ")
+	default:
+		return nil, fmt.Errorf("unsupported config format: %s", format)
+	}
+}
+
+// toJSONCompatible converts the nested yaml maps with interface keys to maps with string keys
+func toJSONCompatible(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(val))
+		for k, v := range val {
+			m[fmt.Sprint(k)] = toJSONCompatible(v)
+		}
+		return m
+	case map[string]interface{}:
+		for k, v := range val {
+			val[k] = toJSONCompatible(v)
+		}
+		return val
+	case []interface{}:
+		for i := range val {
+			val[i] = toJSONCompatible(val[i])
+		}
+		return val
+	default:
+		return v
+	}
+}
+
 // configureValidator configure the struct validator
 func configureValidator(validate *validator.Validate, trans ut.Translator) {
 	validate.RegisterTagNameFunc(func(fld reflect.StructField) string {
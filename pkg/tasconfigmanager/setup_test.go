@@ -0,0 +1,221 @@
+package tasconfigmanager
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/LambdaTest/synapse/config"
+	"github.com/LambdaTest/synapse/pkg/core"
+	"github.com/LambdaTest/synapse/pkg/errs"
+	"github.com/LambdaTest/synapse/pkg/lumber"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEffectiveConfig(t *testing.T) {
+	logger, err := lumber.NewLogger(lumber.LoggingConfig{EnableConsole: true}, true, lumber.InstanceZapLogger)
+	if err != nil {
+		log.Fatalf("Could not instantiate logger %s", err.Error())
+	}
+	tcm := NewTASConfigManager(&config.NucleusConfig{}, logger)
+
+	tasConfig, err := tcm.LoadConfigFromDir(context.TODO(), "testdata", ".tas.yml", core.EventPush, false)
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	// overrides provided in the config file
+	assert.False(t, tasConfig.SmartRun)
+	assert.Equal(t, "v1", tasConfig.Cache.Key)
+	assert.Equal(t, float64(80), tasConfig.CoverageThreshold.Lines)
+	// defaults applied by nucleus
+	assert.Equal(t, core.Small, tasConfig.Tier)
+
+	got, err := MarshalConfig(tasConfig, "yaml")
+	if err != nil {
+		t.Fatalf("failed to marshal config: %v", err)
+	}
+	want, err := ioutil.ReadFile("testdata/effective.golden.yml")
+	if err != nil {
+		t.Fatalf("failed to read golden file: %v", err)
+	}
+	assert.Equal(t, string(want), string(got))
+
+	_, err = MarshalConfig(tasConfig, "toml")
+	assert.NotNil(t, err)
+}
+
+func TestFrameworkOverride(t *testing.T) {
+	logger, err := lumber.NewLogger(lumber.LoggingConfig{EnableConsole: true}, true, lumber.InstanceZapLogger)
+	if err != nil {
+		log.Fatalf("Could not instantiate logger %s", err.Error())
+	}
+
+	tests := []struct {
+		name      string
+		framework string
+		want      string
+		wantErr   error
+	}{
+		{"no override", "", "mocha", nil},
+		{"override", "jest", "jest", nil},
+		{"unsupported framework", "karma", "", errs.ErrUnsupportedFramework},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tcm := NewTASConfigManager(&config.NucleusConfig{Framework: tt.framework}, logger)
+			tasConfig, err := tcm.LoadConfigFromDir(context.TODO(), "testdata", ".tas.yml", core.EventPush, false)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("LoadConfigFromDir() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to load config: %v", err)
+			}
+			assert.Equal(t, tt.want, tasConfig.Framework)
+		})
+	}
+}
+
+func TestExecConcurrencyBounds(t *testing.T) {
+	logger, err := lumber.NewLogger(lumber.LoggingConfig{EnableConsole: true}, true, lumber.InstanceZapLogger)
+	if err != nil {
+		log.Fatalf("Could not instantiate logger %s", err.Error())
+	}
+	base, err := ioutil.ReadFile("testdata/.tas.yml")
+	if err != nil {
+		t.Fatalf("failed to read config: %v", err)
+	}
+	tests := []struct {
+		name        string
+		concurrency string
+		wantErr     bool
+	}{
+		{"within bounds", "8", false},
+		{"upper bound", "64", false},
+		{"above bound", "65", true},
+		{"negative", "-1", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			content := append(append([]byte{}, base...), []byte("\nexecConcurrency: "+tt.concurrency+"\n")...)
+			if err := ioutil.WriteFile(filepath.Join(dir, ".tas.yml"), content, 0644); err != nil {
+				t.Fatalf("failed to write config: %v", err)
+			}
+			tcm := NewTASConfigManager(&config.NucleusConfig{}, logger)
+			_, err := tcm.LoadConfigFromDir(context.TODO(), dir, ".tas.yml", core.EventPush, true)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("LoadConfigFromDir() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestExecutionOrderValidation(t *testing.T) {
+	logger, err := lumber.NewLogger(lumber.LoggingConfig{EnableConsole: true}, true, lumber.InstanceZapLogger)
+	if err != nil {
+		log.Fatalf("Could not instantiate logger %s", err.Error())
+	}
+	base, err := ioutil.ReadFile("testdata/.tas.yml")
+	if err != nil {
+		t.Fatalf("failed to read config: %v", err)
+	}
+	tests := []struct {
+		name    string
+		order   string
+		wantErr bool
+	}{
+		{"declared", "{strategy: declared}", false},
+		{"random with seed", "{strategy: random, seed: 42}", false},
+		{"unknown strategy", "{strategy: reversed}", true},
+		{"seed out of range", "{strategy: random, seed: 4294967296}", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			content := append(append([]byte{}, base...), []byte("\nexecutionOrder: "+tt.order+"\n")...)
+			if err := ioutil.WriteFile(filepath.Join(dir, ".tas.yml"), content, 0644); err != nil {
+				t.Fatalf("failed to write config: %v", err)
+			}
+			tcm := NewTASConfigManager(&config.NucleusConfig{}, logger)
+			_, err := tcm.LoadConfigFromDir(context.TODO(), dir, ".tas.yml", core.EventPush, true)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("LoadConfigFromDir() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDiscoveryWarmupValidation(t *testing.T) {
+	logger, err := lumber.NewLogger(lumber.LoggingConfig{EnableConsole: true}, true, lumber.InstanceZapLogger)
+	if err != nil {
+		log.Fatalf("Could not instantiate logger %s", err.Error())
+	}
+	base, err := ioutil.ReadFile("testdata/.tas.yml")
+	if err != nil {
+		t.Fatalf("failed to read config: %v", err)
+	}
+	tests := []struct {
+		name    string
+		warmup  string
+		wantErr bool
+	}{
+		{"command", `"npm run generate-types"`, false},
+		{"blank", `"  "`, true},
+		{"too long", strings.Repeat("x", 4097), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			content := append(append([]byte{}, base...), []byte("\ndiscoveryWarmup: "+tt.warmup+"\n")...)
+			if err := ioutil.WriteFile(filepath.Join(dir, ".tas.yml"), content, 0644); err != nil {
+				t.Fatalf("failed to write config: %v", err)
+			}
+			tcm := NewTASConfigManager(&config.NucleusConfig{}, logger)
+			_, err := tcm.LoadConfigFromDir(context.TODO(), dir, ".tas.yml", core.EventPush, true)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("LoadConfigFromDir() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDefaultTier(t *testing.T) {
+	logger, err := lumber.NewLogger(lumber.LoggingConfig{EnableConsole: true}, true, lumber.InstanceZapLogger)
+	if err != nil {
+		log.Fatalf("Could not instantiate logger %s", err.Error())
+	}
+
+	tests := []struct {
+		name        string
+		defaultTier string
+		want        core.Tier
+		wantErr     error
+	}{
+		{"not configured", "", core.Small, nil},
+		{"configured", "medium", core.Medium, nil},
+		{"unknown tier", "huge", "", errs.ErrUnknownTier},
+		{"internal tier", "internal", "", errs.ErrUnknownTier},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tcm := NewTASConfigManager(&config.NucleusConfig{DefaultTier: tt.defaultTier}, logger)
+			// the configuration file does not set the tier
+			tasConfig, err := tcm.LoadConfigFromDir(context.TODO(), "testdata", ".tas.yml", core.EventPush, false)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to load config: %v", err)
+			}
+			assert.Equal(t, tt.want, tasConfig.Tier)
+		})
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/LambdaTest/synapse/pkg/core"
 	"github.com/LambdaTest/synapse/pkg/global"
 	"github.com/LambdaTest/synapse/pkg/lumber"
+	"github.com/LambdaTest/synapse/pkg/utils"
 )
 
 // task represents each instance of nucleus spawned by neuron
@@ -21,6 +22,10 @@ type task struct {
 	client   http.Client
 	endpoint string
 	logger   lumber.Logger
+	offline  bool
+	// maxAttempts is the maximum number of attempts to update the status
+	maxAttempts int
+	retryDelay  time.Duration
 }
 
 // New returns new task
@@ -30,10 +35,18 @@ func New(ctx context.Context, cfg *config.NucleusConfig, logger lumber.Logger) (
 		client:   http.Client{Timeout: 30 * time.Second},
 		logger:   logger,
 		endpoint: global.NeuronHost + "/task",
+		offline:  cfg.Offline,
+		// the first attempt is not a retry
+		maxAttempts: cfg.StatusUpdateRetries + 1,
+		retryDelay:  time.Second,
 	}, nil
 }
 
 func (t *task) UpdateStatus(payload *core.TaskPayload) error {
+	if t.offline {
+		t.logger.Infof("offline mode, status of task: %s is %s, remark: %s", payload.TaskID, payload.Status, payload.Remark)
+		return nil
+	}
 
 	t.logger.Debugf("sending status update of task: %s to %s for repository: %s", payload.TaskID, payload.Status, payload.RepoLink)
 	reqBody, err := json.Marshal(payload)
@@ -42,17 +55,39 @@ func (t *task) UpdateStatus(payload *core.TaskPayload) error {
 		return err
 	}
 
+	delay := t.retryDelay
+	for attempt := 1; ; attempt++ {
+		retryable, err := t.sendStatus(reqBody)
+		if err == nil {
+			return nil
+		}
+		if !retryable || attempt >= t.maxAttempts {
+			return err
+		}
+		t.logger.Warnf("attempt %d/%d to update task status failed, retrying in %s", attempt, t.maxAttempts, delay)
+		select {
+		case <-t.ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// sendStatus sends the status update to neuron, it reports whether a failure is transient and can be retried.
+func (t *task) sendStatus(reqBody []byte) (retryable bool, err error) {
 	req, err := http.NewRequestWithContext(t.ctx, http.MethodPut, t.endpoint, bytes.NewBuffer(reqBody))
 
 	if err != nil {
 		t.logger.Errorf("error while creating http request %v", err)
-		return err
+		return false, err
 	}
+	utils.AddNeuronHeaders(req)
 
 	resp, err := t.client.Do(req)
 	if err != nil {
 		t.logger.Errorf("error while sending http request %v", err)
-		return err
+		return true, err
 	}
 
 	defer resp.Body.Close()
@@ -60,14 +95,14 @@ func (t *task) UpdateStatus(payload *core.TaskPayload) error {
 	respBody, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		t.logger.Errorf("error while sending http response body %v", err)
-		return err
+		return true, err
 	}
 
 	if resp.StatusCode != http.StatusOK {
 		t.logger.Errorf("non 200 status code %s", string(respBody))
-		return errors.New("non 200 status code")
+		retryable = resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests
+		return retryable, errors.New("non 200 status code")
 	}
 
-	return nil
-
+	return false, nil
 }
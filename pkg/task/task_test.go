@@ -0,0 +1,82 @@
+package task
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/LambdaTest/synapse/pkg/core"
+	"github.com/LambdaTest/synapse/pkg/global"
+	"github.com/LambdaTest/synapse/pkg/lumber"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateStatusRetry(t *testing.T) {
+	logger, err := lumber.NewLogger(lumber.LoggingConfig{EnableConsole: true}, true, lumber.InstanceZapLogger)
+	if err != nil {
+		log.Fatalf("Could not instantiate logger %s", err.Error())
+	}
+
+	tests := []struct {
+		name         string
+		statusCodes  []int
+		maxAttempts  int
+		wantErr      bool
+		wantRequests int
+	}{
+		{"success after transient failures", []int{http.StatusBadGateway, http.StatusTooManyRequests, http.StatusOK}, 4, false, 3},
+		{"client error is not retried", []int{http.StatusBadRequest, http.StatusOK}, 4, true, 1},
+		{"retry ceiling", []int{http.StatusServiceUnavailable}, 3, true, 3},
+		{"retries disabled", []int{http.StatusServiceUnavailable}, 1, true, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				code := tt.statusCodes[len(tt.statusCodes)-1]
+				if requests < len(tt.statusCodes) {
+					code = tt.statusCodes[requests]
+				}
+				requests++
+				w.WriteHeader(code)
+			}))
+			defer server.Close()
+
+			tk := &task{
+				ctx:         context.TODO(),
+				endpoint:    server.URL,
+				logger:      logger,
+				maxAttempts: tt.maxAttempts,
+			}
+			err := tk.UpdateStatus(&core.TaskPayload{TaskID: "task", Status: core.Passed})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("UpdateStatus() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			assert.Equal(t, tt.wantRequests, requests)
+		})
+	}
+}
+
+func TestUpdateStatusNeuronHeaders(t *testing.T) {
+	logger, err := lumber.NewLogger(lumber.LoggingConfig{EnableConsole: true}, true, lumber.InstanceZapLogger)
+	if err != nil {
+		log.Fatalf("Could not instantiate logger %s", err.Error())
+	}
+	defer global.SetNeuronHeaders(nil)
+	global.SetNeuronHeaders(map[string]string{"X-Api-Key": "key-123", "X-Tenant-Id": "tenant"})
+
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+	}))
+	defer server.Close()
+
+	tk := &task{ctx: context.TODO(), endpoint: server.URL, logger: logger, maxAttempts: 1}
+	if err := tk.UpdateStatus(&core.TaskPayload{TaskID: "task", Status: core.Passed}); err != nil {
+		t.Fatalf("UpdateStatus() error = %v", err)
+	}
+	assert.Equal(t, "key-123", header.Get("X-Api-Key"))
+	assert.Equal(t, "tenant", header.Get("X-Tenant-Id"))
+}
@@ -0,0 +1,72 @@
+package testblocklistservice
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/LambdaTest/synapse/pkg/utils"
+)
+
+// cachedBlocklist is the remote blocklist of a repo kept on disk along with the validators
+// of the response, so that it is downloaded again only when it changed
+type cachedBlocklist struct {
+	ETag         string          `json:"etag,omitempty"`
+	LastModified string          `json:"lastModified,omitempty"`
+	Body         json.RawMessage `json:"body"`
+}
+
+// cacheName returns the name of the cached blocklist of the repo within the cache dir
+func cacheName(repoID string) string {
+	return "blocklist-" + url.PathEscape(repoID) + ".json"
+}
+
+// readCache returns the cached blocklist of the repo, nil if the cache is disabled or there is none
+func (tbs *TestBlockListService) readCache(repoID string) *cachedBlocklist {
+	if tbs.cfg.BlocklistCacheDir == "" {
+		return nil
+	}
+	data, err := ioutil.ReadFile(filepath.Join(tbs.cfg.BlocklistCacheDir, cacheName(repoID)))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			tbs.logger.Warnf("Unable to read the cached blocklist, fetching the full blocklist: %v", err)
+		}
+		return nil
+	}
+	cached := new(cachedBlocklist)
+	if err := json.Unmarshal(data, cached); err != nil || (cached.ETag == "" && cached.LastModified == "") {
+		tbs.logger.Warnf("Ignoring invalid cached blocklist, error: %v", err)
+		return nil
+	}
+	return cached
+}
+
+// writeCache caches the blocklist of the repo if the response can be validated later,
+// a failure is only logged
+func (tbs *TestBlockListService) writeCache(repoID string, header http.Header, body []byte) {
+	if tbs.cfg.BlocklistCacheDir == "" {
+		return
+	}
+	cached := cachedBlocklist{ETag: header.Get("ETag"), LastModified: header.Get("Last-Modified"), Body: body}
+	if cached.ETag == "" && cached.LastModified == "" {
+		// the blocklist could never be revalidated
+		tbs.removeCache(repoID)
+		return
+	}
+	if err := utils.WriteWorkFile(tbs.cfg.BlocklistCacheDir, cacheName(repoID), cached); err != nil {
+		tbs.logger.Warnf("Unable to cache the blocklist: %v", err)
+	}
+}
+
+// removeCache removes the cached blocklist of the repo
+func (tbs *TestBlockListService) removeCache(repoID string) {
+	if tbs.cfg.BlocklistCacheDir == "" {
+		return
+	}
+	if err := os.Remove(filepath.Join(tbs.cfg.BlocklistCacheDir, cacheName(repoID))); err != nil && !os.IsNotExist(err) {
+		tbs.logger.Warnf("Unable to remove the cached blocklist: %v", err)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/url"
@@ -16,10 +17,13 @@ import (
 	"github.com/LambdaTest/synapse/pkg/core"
 	"github.com/LambdaTest/synapse/pkg/global"
 	"github.com/LambdaTest/synapse/pkg/lumber"
+	"github.com/LambdaTest/synapse/pkg/utils"
 )
 
 const (
 	delimiter = "##"
+	// fetchAttempts is the number of times the blocklist is requested from neuron
+	fetchAttempts = 3
 )
 
 //blocklist represents the blocklisted test suites and test cases.
@@ -44,10 +48,16 @@ type TestBlockListService struct {
 	blocklistedEntities map[string][]blocklist
 	once                sync.Once
 	errChan             chan error
+	retryDelay          time.Duration
 }
 
 // NewTestBlockListService creates and returns a new TestBlockListService instance
 func NewTestBlockListService(cfg *config.NucleusConfig, logger lumber.Logger) (*TestBlockListService, error) {
+	switch cfg.BlocklistFailureMode {
+	case global.BlocklistFailureStrict, global.BlocklistFailureLenient, "":
+	default:
+		return nil, fmt.Errorf("invalid blocklist failure mode %q", cfg.BlocklistFailureMode)
+	}
 
 	return &TestBlockListService{
 		cfg:                 cfg,
@@ -55,6 +65,7 @@ func NewTestBlockListService(cfg *config.NucleusConfig, logger lumber.Logger) (*
 		endpoint:            global.NeuronHost + "/blocklist",
 		blocklistedEntities: make(map[string][]blocklist),
 		errChan:             make(chan error, 1),
+		retryDelay:          time.Second,
 		httpClient: http.Client{
 			Timeout: 15 * time.Second,
 			Transport: &http.Transport{
@@ -82,6 +93,17 @@ func (tbs *TestBlockListService) fetchBlockListFromNeuron(ctx context.Context, r
 		tbs.logger.Errorf("Unable to fetch blocklist response: %+v", err)
 		return err
 	}
+	utils.AddNeuronHeaders(req)
+	cached := tbs.readCache(repoID)
+	if cached != nil {
+		// neuron answers not modified if the cached blocklist is still current
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
 
 	resp, err := tbs.httpClient.Do(req)
 	if err != nil {
@@ -91,25 +113,33 @@ func (tbs *TestBlockListService) fetchBlockListFromNeuron(ctx context.Context, r
 
 	defer resp.Body.Close()
 
-	if resp.StatusCode == http.StatusNotFound {
+	var body []byte
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached != nil:
+		tbs.logger.Infof("Remote blocklist is unchanged, using the cached blocklist")
+		body = cached.Body
+	case resp.StatusCode == http.StatusNotFound:
+		tbs.removeCache(repoID)
 		return nil
-	}
-	if resp.StatusCode != http.StatusOK {
+	case resp.StatusCode != http.StatusOK:
 		err = errors.New("non 200 status")
 		tbs.logger.Errorf("Unable to fetch blocklist response: %v", err)
 		return err
-	}
-
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		tbs.logger.Errorf("Unable to fetch blocklist response: %v", err)
-		return err
+	default:
+		body, err = ioutil.ReadAll(resp.Body)
+		if err != nil {
+			tbs.logger.Errorf("Unable to fetch blocklist response: %v", err)
+			return err
+		}
 	}
 
 	if jsonErr := json.Unmarshal(body, &inp); jsonErr != nil {
 		tbs.logger.Errorf("Unable to fetch blocklist response: %v", jsonErr)
 		return jsonErr
 	}
+	if resp.StatusCode == http.StatusOK {
+		tbs.writeCache(repoID, resp.Header, body)
+	}
 	// populate bl
 
 	locators := make([]string, 0, len(inp))
@@ -120,14 +150,46 @@ func (tbs *TestBlockListService) fetchBlockListFromNeuron(ctx context.Context, r
 	return nil
 }
 
+// fetchBlockList fetches the blocklist from neuron with retries. In lenient mode a failure
+// is logged and the task continues without the remote blocklist.
+func (tbs *TestBlockListService) fetchBlockList(ctx context.Context, repoID string, warns *core.Warnings) error {
+	if tbs.cfg.Offline {
+		tbs.logger.Infof("offline mode, skipping remote blocklist")
+		return nil
+	}
+	var err error
+	for attempt := 1; attempt <= fetchAttempts; attempt++ {
+		if err = tbs.fetchBlockListFromNeuron(ctx, repoID); err == nil {
+			return nil
+		}
+		tbs.logger.Warnf("Attempt %d/%d to fetch remote blocklist failed: %v", attempt, fetchAttempts, err)
+		if attempt == fetchAttempts {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(tbs.retryDelay):
+		}
+	}
+	if tbs.cfg.BlocklistFailureMode == global.BlocklistFailureLenient {
+		warns.Addf("Unable to fetch remote blocklist: %v. Continuing without remote blocklist", err)
+		return nil
+	}
+	tbs.logger.Errorf("Unable to fetch remote blocklist: %v", err)
+	return err
+}
+
 // GetBlockListedTests provides list of blocklisted test cases
-func (tbs *TestBlockListService) GetBlockListedTests(ctx context.Context, tasConfig *core.TASConfig, repoID string) error {
+func (tbs *TestBlockListService) GetBlockListedTests(ctx context.Context,
+	tasConfig *core.TASConfig,
+	repoID string,
+	warns *core.Warnings) error {
 
 	tbs.once.Do(func() {
 		tbs.populateBlockList("yml", tasConfig.Blocklist)
 
-		if err := tbs.fetchBlockListFromNeuron(ctx, repoID); err != nil {
-			tbs.logger.Errorf("Unable to fetch remote blocklist: %v. Ignoring remote response", err)
+		if err := tbs.fetchBlockList(ctx, repoID, warns); err != nil {
 			tbs.errChan <- err
 			return
 		}
@@ -0,0 +1,133 @@
+package testblocklistservice
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/LambdaTest/synapse/config"
+	"github.com/LambdaTest/synapse/pkg/core"
+	"github.com/LambdaTest/synapse/pkg/global"
+	"github.com/LambdaTest/synapse/pkg/lumber"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFetchBlockList(t *testing.T) {
+	logger, err := lumber.NewLogger(lumber.LoggingConfig{EnableConsole: true}, true, lumber.InstanceZapLogger)
+	if err != nil {
+		log.Fatalf("Could not instantiate logger %s", err.Error())
+	}
+
+	tests := []struct {
+		name         string
+		mode         string
+		failures     int32
+		wantErr      bool
+		wantRequests int32
+		wantBlocked  bool
+		wantWarnings int
+	}{
+		{"succeeds after retry", global.BlocklistFailureStrict, 2, false, 3, true, 0},
+		{"strict fails", global.BlocklistFailureStrict, fetchAttempts, true, fetchAttempts, false, 0},
+		{"lenient continues", global.BlocklistFailureLenient, fetchAttempts, false, fetchAttempts, false, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if atomic.AddInt32(&requests, 1) <= tt.failures {
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+				w.Write([]byte(`[{"name": "flaky", "repo": "repo", "test_locator": "test/a.spec.js##suite##flaky"}]`)) // nolint:errcheck
+			}))
+			defer server.Close()
+
+			tbs, err := NewTestBlockListService(&config.NucleusConfig{BlocklistFailureMode: tt.mode}, logger)
+			if err != nil {
+				t.Fatalf("failed to create blocklist service: %v", err)
+			}
+			tbs.endpoint = server.URL
+			tbs.retryDelay = 0
+
+			warns := core.NewWarnings(logger)
+			err = tbs.fetchBlockList(context.TODO(), "repoID", warns)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("fetchBlockList() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			assert.Equal(t, tt.wantRequests, atomic.LoadInt32(&requests))
+			_, blocked := tbs.blocklistedEntities["test/a.spec.js"]
+			assert.Equal(t, tt.wantBlocked, blocked)
+			assert.Len(t, warns.List(), tt.wantWarnings)
+		})
+	}
+
+	_, err = NewTestBlockListService(&config.NucleusConfig{BlocklistFailureMode: "ignore"}, logger)
+	assert.NotNil(t, err)
+}
+
+func TestFetchBlockListCache(t *testing.T) {
+	logger, err := lumber.NewLogger(lumber.LoggingConfig{EnableConsole: true}, true, lumber.InstanceZapLogger)
+	if err != nil {
+		log.Fatalf("Could not instantiate logger %s", err.Error())
+	}
+	blocklists := map[string]string{
+		`"v1"`: `[{"name": "flaky", "repo": "repo", "test_locator": "test/a.spec.js##suite##flaky"}]`,
+		`"v2"`: `[{"name": "slow", "repo": "repo", "test_locator": "test/b.spec.js##suite##slow"}]`,
+	}
+	etag := `"v1"`
+	var notModified int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if etag == "" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		if r.Header.Get("If-None-Match") == etag {
+			atomic.AddInt32(&notModified, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Write([]byte(blocklists[etag])) // nolint:errcheck
+	}))
+	defer server.Close()
+
+	cacheDir := t.TempDir()
+	fetch := func() map[string][]blocklist {
+		tbs, err := NewTestBlockListService(&config.NucleusConfig{BlocklistCacheDir: cacheDir}, logger)
+		if err != nil {
+			t.Fatalf("failed to create blocklist service: %v", err)
+		}
+		tbs.endpoint = server.URL
+		if err := tbs.fetchBlockList(context.TODO(), "repoID", core.NewWarnings(logger)); err != nil {
+			t.Fatalf("fetchBlockList() error = %v", err)
+		}
+		return tbs.blocklistedEntities
+	}
+
+	// cache miss
+	assert.Contains(t, fetch(), "test/a.spec.js")
+	assert.Equal(t, int32(0), atomic.LoadInt32(&notModified))
+	assert.FileExists(t, filepath.Join(cacheDir, cacheName("repoID")))
+
+	// unchanged, the cached blocklist is used
+	assert.Contains(t, fetch(), "test/a.spec.js")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&notModified))
+
+	// changed, the blocklist is downloaded again and cached
+	etag = `"v2"`
+	entities := fetch()
+	assert.Contains(t, entities, "test/b.spec.js")
+	assert.NotContains(t, entities, "test/a.spec.js")
+	assert.Contains(t, fetch(), "test/b.spec.js")
+	assert.Equal(t, int32(2), atomic.LoadInt32(&notModified))
+
+	// removed
+	etag = ""
+	assert.Empty(t, fetch())
+	assert.NoFileExists(t, filepath.Join(cacheDir, cacheName("repoID")))
+}
@@ -3,22 +3,32 @@ package testdiscoveryservice
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
 	"os/exec"
+	"sort"
+	"strings"
 
+	"github.com/LambdaTest/synapse/config"
 	"github.com/LambdaTest/synapse/pkg/core"
+	"github.com/LambdaTest/synapse/pkg/errs"
 	"github.com/LambdaTest/synapse/pkg/global"
 	"github.com/LambdaTest/synapse/pkg/logstream"
 	"github.com/LambdaTest/synapse/pkg/lumber"
+	"github.com/LambdaTest/synapse/pkg/utils"
 )
 
 type testDiscoveryService struct {
+	cfg         *config.NucleusConfig
 	logger      lumber.Logger
 	execManager core.ExecutionManager
 }
 
 // NewTestDiscoveryService creates and returns a new testDiscoveryService instance
-func NewTestDiscoveryService(execManager core.ExecutionManager, logger lumber.Logger) core.TestDiscoveryService {
-	tds := testDiscoveryService{logger: logger, execManager: execManager}
+func NewTestDiscoveryService(execManager core.ExecutionManager, cfg *config.NucleusConfig, logger lumber.Logger) core.TestDiscoveryService {
+	tds := testDiscoveryService{cfg: cfg, logger: logger, execManager: execManager}
 	return &tds
 }
 
@@ -26,7 +36,8 @@ func (tds *testDiscoveryService) Discover(ctx context.Context,
 	tasConfig *core.TASConfig,
 	payload *core.Payload,
 	secretData map[string]string,
-	diff map[string]int) error {
+	diff map[string]int,
+	warns *core.Warnings) error {
 	var target []string
 	var envMap map[string]string
 	if payload.EventType == core.EventPullRequest {
@@ -36,51 +47,244 @@ func (tds *testDiscoveryService) Discover(ctx context.Context,
 		target = tasConfig.Postmerge.Patterns
 		envMap = tasConfig.Postmerge.EnvMap
 	}
-	tasYmlModified := false
-	if _, ok := diff[payload.TasFileName]; ok {
-		tasYmlModified = true
+	if !tasConfig.SkipFrameworkEnv {
+		envMap = utils.FrameworkEnv(tasConfig.Framework, envMap)
 	}
-
-	// discover all tests if tas.yml modified or if parent commit does not exists or smart run feature is set to false
-	discoverAll := tasYmlModified || !payload.ParentCommitCoverageExists || !tasConfig.SmartRun
-
-	args := []string{"--command", "discover"}
-	if !discoverAll {
-		for k, v := range diff {
-			// in changed files we only have added or modified files.
-			if v != core.FileRemoved {
-				args = append(args, "--diff", k)
-			}
+	var cmd *exec.Cmd
+	var customEnv []string
+	if tasConfig.DiscoverCommand != "" {
+		var cleanup func()
+		var err error
+		cmd, customEnv, cleanup, err = tds.buildCustomCommand(ctx, tasConfig, payload, target, diff, warns)
+		if err != nil {
+			return err
 		}
-	}
-	if tasConfig.ConfigFile != "" {
-		args = append(args, "--config", tasConfig.ConfigFile)
-	}
-
-	for _, pattern := range target {
-		args = append(args, "--pattern", pattern)
+		defer cleanup()
+	} else {
+		args, err := tds.buildArgs(tasConfig, payload, target, diff, warns)
+		if err != nil {
+			return err
+		}
+		cmd = exec.Command(global.FrameworkRunnerMap[tasConfig.Framework], args...)
 	}
 	tds.logger.Debugf("Discovering tests at paths %+v", target)
+	maskedArgs := make([]string, 0, len(cmd.Args))
+	for _, arg := range cmd.Args {
+		maskedArgs = append(maskedArgs, logstream.MaskString(arg, secretData))
+	}
+	tds.writeWorkFile(global.WorkDiscoveryCommandFile, discoveryCommand{
+		Framework: tasConfig.Framework,
+		Args:      maskedArgs,
+		Patterns:  target,
+	})
 
-	cmd := exec.CommandContext(ctx, global.FrameworkRunnerMap[tasConfig.Framework], args...)
 	cmd.Dir = global.RepoDir
 	envVars, err := tds.execManager.GetEnvVariables(envMap, secretData)
 	if err != nil {
 		tds.logger.Errorf("failed to parsed env variables, error: %v", err)
 		return err
 	}
-	cmd.Env = envVars
+	cmd.Env = append(envVars, customEnv...)
 	logWriter := lumber.NewWriter(tds.logger)
 	defer logWriter.Close()
-	maskWriter := logstream.NewMasker(logWriter, secretData)
+	limitWriter := logstream.NewLimiter(logWriter, tds.cfg.CommandLogLimit*global.MB)
+	defer limitWriter.Close()
+	// the tail of the output classifies the failure of the runner
+	outputTail := logstream.NewTail(global.FailureOutputTail)
+	maskWriter := logstream.NewMasker(io.MultiWriter(limitWriter, outputTail), secretData)
 	cmd.Stdout = maskWriter
 	cmd.Stderr = maskWriter
 
+	if err := tds.runDiscovery(ctx, cmd, tasConfig.DiscoveryWarmup); err != nil {
+		return &errs.CommandError{Err: err, Output: outputTail.String()}
+	}
+	return nil
+}
+
+// runDiscovery runs the discovery command, preceded by the warmup command if there is one. The warmup
+// runs in the directory and the environment of the discovery command, its output is logged alike.
+// Both are started in their own process group, so that the processes they spawn are killed along with them.
+func (tds *testDiscoveryService) runDiscovery(ctx context.Context, cmd *exec.Cmd, warmup string) error {
+	if warmup != "" {
+		warmupCmd := exec.Command("/bin/bash", "-c", warmup)
+		warmupCmd.Dir = cmd.Dir
+		warmupCmd.Env = cmd.Env
+		warmupCmd.Stdout = cmd.Stdout
+		warmupCmd.Stderr = cmd.Stderr
+		tds.logger.Debugf("Executing discovery warmup command: %s", warmup)
+		wait, err := tds.execManager.StartProcessGroup(ctx, warmupCmd)
+		if err == nil {
+			err = wait()
+		}
+		if err != nil {
+			tds.logger.Errorf("discovery warmup command %s failed with error: %v", warmup, err)
+			return fmt.Errorf("%w: %v", errs.ErrDiscoveryWarmup, err)
+		}
+	}
+
+	if err := tds.execManager.Deprioritize(cmd); err != nil {
+		tds.logger.Warnf("failed to set the nice level of the discovery command, error: %v", err)
+	}
 	tds.logger.Debugf("Executing test discovery command: %s", cmd.String())
-	if err := cmd.Run(); err != nil {
+	wait, err := tds.execManager.StartProcessGroup(ctx, cmd)
+	if err != nil {
+		tds.logger.Errorf("command %s of type %s failed with error: %v", cmd.String(), core.Discovery, err)
+		return err
+	}
+	if err := wait(); err != nil {
 		tds.logger.Errorf("command %s of type %s failed with error: %v", cmd.String(), core.Discovery, err)
 		return err
 	}
-
 	return nil
 }
+
+// buildArgs creates the arguments passed to the framework runner for discovering tests
+func (tds *testDiscoveryService) buildArgs(tasConfig *core.TASConfig,
+	payload *core.Payload,
+	target []string,
+	diff map[string]int,
+	warns *core.Warnings) ([]string, error) {
+	discoverAll, changedFiles, err := tds.diffScope(tasConfig, payload, diff, warns)
+	if err != nil {
+		return nil, err
+	}
+	tds.writeWorkFile(global.WorkDiffFile, resolvedDiff{DiscoverAll: discoverAll, ChangedFiles: changedFiles, Diff: diff})
+
+	tmpl, ok := global.FrameworkDiscoveryArgs[tasConfig.Framework]
+	if !ok {
+		tmpl = global.DefaultDiscoveryArgs
+	}
+	args := tmpl.Command.Render("")
+	if !discoverAll {
+		if len(changedFiles) == 0 {
+			// a bare diff flag tells the runner that nothing has changed
+			args = append(args, tmpl.EmptyDiff.Render("")...)
+		}
+		for _, k := range changedFiles {
+			args = append(args, tmpl.Diff.Render(k)...)
+		}
+	}
+	if tasConfig.ConfigFile != "" {
+		args = append(args, tmpl.Config.Render(tasConfig.ConfigFile)...)
+	}
+
+	for _, pattern := range target {
+		args = append(args, tmpl.Pattern.Render(pattern)...)
+	}
+	return args, nil
+}
+
+// resolvedDiff is the record of the changed files the tests are discovered for
+type resolvedDiff struct {
+	DiscoverAll  bool           `json:"discoverAll"`
+	ChangedFiles []string       `json:"changedFiles"`
+	Diff         map[string]int `json:"diff"`
+}
+
+// discoveryCommand is the record of the discovery command
+type discoveryCommand struct {
+	Framework string   `json:"framework"`
+	Args      []string `json:"args"`
+	Patterns  []string `json:"patterns"`
+}
+
+// writeWorkFile records v in the work directory, if one is configured. The record only serves
+// inspection, a failure is logged.
+func (tds *testDiscoveryService) writeWorkFile(name string, v interface{}) {
+	if tds.cfg.WorkDir == "" {
+		return
+	}
+	if err := utils.WriteWorkFile(tds.cfg.WorkDir, name, v); err != nil {
+		tds.logger.Warnf("failed to write %s to the work directory, error: %v", name, err)
+	}
+}
+
+// diffScope decides whether all the tests have to be discovered, otherwise it returns the sorted
+// list of changed files the tests have to be discovered for. An empty list means nothing has changed.
+func (tds *testDiscoveryService) diffScope(tasConfig *core.TASConfig,
+	payload *core.Payload,
+	diff map[string]int,
+	warns *core.Warnings) (discoverAll bool, changedFiles []string, err error) {
+	_, tasYmlModified := diff[payload.TasFileName]
+	// discover all tests if tas.yml modified or if parent commit does not exists or smart run feature is set to false
+	if tasYmlModified || !payload.ParentCommitCoverageExists || !tasConfig.SmartRun {
+		return true, nil, nil
+	}
+
+	// diff was fetched successfully but has no changes, eg. a commit added and reverted in the same PR
+	if payload.EventType == core.EventPullRequest && diff != nil && len(diff) == 0 {
+		warns.Addf("Empty diff found for pull request %d of repo %s, falling back to discover %s",
+			payload.PullRequestNumber, payload.RepoSlug, tds.cfg.EmptyDiffFallback)
+		switch tds.cfg.EmptyDiffFallback {
+		case global.EmptyDiffDiscoverNone:
+			return false, []string{}, nil
+		case global.EmptyDiffDiscoverAll, "":
+			return true, nil, nil
+		default:
+			return false, nil, fmt.Errorf("invalid empty diff fallback %q", tds.cfg.EmptyDiffFallback)
+		}
+	}
+
+	// sort the changed files so that the runner receives them in a stable order across runs
+	changedFiles = make([]string, 0, len(diff))
+	for k, v := range diff {
+		// in changed files we only have added or modified files.
+		if v != core.FileRemoved {
+			changedFiles = append(changedFiles, k)
+		}
+	}
+	if len(changedFiles) == 0 {
+		// without any changed files the runner discovers all the tests
+		return true, nil, nil
+	}
+	sort.Strings(changedFiles)
+	return false, changedFiles, nil
+}
+
+// buildCustomCommand creates the user defined discovery command from tas.yml along with the
+// environment variables describing what has to be discovered. The command is run through `sh -c`
+// with the following contract:
+//   - TAS_DISCOVER_ALL is "true" if all the tests have to be discovered.
+//   - TAS_DIFF_FILE is the path of a file listing the changed files, one per line.
+//     It is empty when all the tests have to be discovered.
+//   - TAS_PATTERNS lists the glob patterns of the test files, one per line.
+//   - TAS_CONFIG_FILE is the framework config file from tas.yml, if any.
+//
+// The command must post the discovered tests to ENDPOINT_POST_TEST_LIST, as the framework runners do.
+// The returned cleanup removes the diff file.
+func (tds *testDiscoveryService) buildCustomCommand(ctx context.Context,
+	tasConfig *core.TASConfig,
+	payload *core.Payload,
+	target []string,
+	diff map[string]int,
+	warns *core.Warnings) (cmd *exec.Cmd, env []string, cleanup func(), err error) {
+	discoverAll, changedFiles, err := tds.diffScope(tasConfig, payload, diff, warns)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	tds.writeWorkFile(global.WorkDiffFile, resolvedDiff{DiscoverAll: discoverAll, ChangedFiles: changedFiles, Diff: diff})
+	diffFile, err := ioutil.TempFile(tds.cfg.TempDir, "tas-diff-*.txt")
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	cleanup = func() { os.Remove(diffFile.Name()) }
+	for _, file := range changedFiles {
+		if _, err := fmt.Fprintln(diffFile, file); err != nil {
+			diffFile.Close()
+			cleanup()
+			return nil, nil, nil, err
+		}
+	}
+	if err := diffFile.Close(); err != nil {
+		cleanup()
+		return nil, nil, nil, err
+	}
+
+	env = []string{
+		fmt.Sprintf("TAS_DISCOVER_ALL=%t", discoverAll),
+		fmt.Sprintf("TAS_DIFF_FILE=%s", diffFile.Name()),
+		fmt.Sprintf("TAS_PATTERNS=%s", strings.Join(target, "\n")),
+		fmt.Sprintf("TAS_CONFIG_FILE=%s", tasConfig.ConfigFile),
+	}
+	return exec.Command("sh", "-c", tasConfig.DiscoverCommand), env, cleanup, nil
+}
@@ -0,0 +1,268 @@
+package testdiscoveryservice
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/LambdaTest/synapse/config"
+	"github.com/LambdaTest/synapse/pkg/core"
+	"github.com/LambdaTest/synapse/pkg/errs"
+	"github.com/LambdaTest/synapse/pkg/global"
+	"github.com/LambdaTest/synapse/pkg/lumber"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestDiscoveryService(t *testing.T, cfg *config.NucleusConfig) *testDiscoveryService {
+	logger, err := lumber.NewLogger(lumber.LoggingConfig{EnableConsole: true}, true, lumber.InstanceZapLogger)
+	if err != nil {
+		t.Fatalf("Could not instantiate logger %s", err.Error())
+	}
+	return &testDiscoveryService{cfg: cfg, logger: logger}
+}
+
+func TestBuildArgsEmptyPRDiff(t *testing.T) {
+	tasConfig := &core.TASConfig{SmartRun: true}
+	payload := &core.Payload{
+		EventType:                  core.EventPullRequest,
+		TasFileName:                ".tas.yml",
+		ParentCommitCoverageExists: true,
+	}
+	target := []string{"./test/**/*.spec.js"}
+
+	tests := []struct {
+		name     string
+		fallback string
+		want     []string
+	}{
+		{"discover all", global.EmptyDiffDiscoverAll, []string{"--command", "discover", "--pattern", "./test/**/*.spec.js"}},
+		{"discover none", global.EmptyDiffDiscoverNone, []string{"--command", "discover", "--diff", "--pattern", "./test/**/*.spec.js"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tds := newTestDiscoveryService(t, &config.NucleusConfig{EmptyDiffFallback: tt.fallback})
+			warns := core.NewWarnings(tds.logger)
+			args, err := tds.buildArgs(tasConfig, payload, target, map[string]int{}, warns)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, args)
+			assert.Len(t, warns.List(), 1)
+		})
+	}
+
+	tds := newTestDiscoveryService(t, &config.NucleusConfig{EmptyDiffFallback: "some"})
+	_, err := tds.buildArgs(tasConfig, payload, target, map[string]int{}, core.NewWarnings(tds.logger))
+	assert.NotNil(t, err)
+}
+
+func TestBuildArgsDeterministicDiff(t *testing.T) {
+	tasConfig := &core.TASConfig{SmartRun: true}
+	payload := &core.Payload{
+		EventType:                  core.EventPush,
+		TasFileName:                ".tas.yml",
+		ParentCommitCoverageExists: true,
+	}
+	diff := map[string]int{
+		"src/b.js":           core.FileModified,
+		"test/a.spec.js":     core.FileAdded,
+		"src/a.js":           core.FileModified,
+		"src/removed.js":     core.FileRemoved,
+		"src/nested/c.js":    core.FileAdded,
+		"test/nested/b.spec": core.FileModified,
+	}
+	want := []string{"--command", "discover",
+		"--diff", "src/a.js",
+		"--diff", "src/b.js",
+		"--diff", "src/nested/c.js",
+		"--diff", "test/a.spec.js",
+		"--diff", "test/nested/b.spec",
+		"--pattern", "./test/**/*.spec.js"}
+
+	tds := newTestDiscoveryService(t, &config.NucleusConfig{})
+	for i := 0; i < 10; i++ {
+		args, err := tds.buildArgs(tasConfig, payload, []string{"./test/**/*.spec.js"}, diff, core.NewWarnings(tds.logger))
+		assert.Nil(t, err)
+		assert.Equal(t, want, args)
+	}
+}
+
+func TestBuildCustomCommand(t *testing.T) {
+	tasConfig := &core.TASConfig{
+		SmartRun:        true,
+		ConfigFile:      ".mocharc.yml",
+		DiscoverCommand: `printf '%s|%s|%s\n' "$TAS_DISCOVER_ALL" "$TAS_PATTERNS" "$TAS_CONFIG_FILE" > out.txt && cat "$TAS_DIFF_FILE" >> out.txt`,
+	}
+	target := []string{"./test/**/*.spec.js", "./e2e/**/*.spec.js"}
+
+	tests := []struct {
+		name    string
+		payload *core.Payload
+		diff    map[string]int
+		want    string
+	}{
+		{
+			name:    "changed files",
+			payload: &core.Payload{EventType: core.EventPush, TasFileName: ".tas.yml", ParentCommitCoverageExists: true},
+			diff:    map[string]int{"src/b.js": core.FileModified, "src/a.js": core.FileAdded, "src/c.js": core.FileRemoved},
+			want:    "false|./test/**/*.spec.js\n./e2e/**/*.spec.js|.mocharc.yml\nsrc/a.js\nsrc/b.js\n",
+		},
+		{
+			name:    "discover all",
+			payload: &core.Payload{EventType: core.EventPush, TasFileName: ".tas.yml"},
+			diff:    map[string]int{"src/a.js": core.FileAdded},
+			want:    "true|./test/**/*.spec.js\n./e2e/**/*.spec.js|.mocharc.yml\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			tds := newTestDiscoveryService(t, &config.NucleusConfig{TempDir: tempDir})
+			cmd, env, cleanup, err := tds.buildCustomCommand(context.TODO(), tasConfig, tt.payload, target, tt.diff, core.NewWarnings(tds.logger))
+			if err != nil {
+				t.Fatalf("buildCustomCommand() error = %v", err)
+			}
+			defer cleanup()
+			diffFiles, _ := filepath.Glob(filepath.Join(tempDir, "tas-diff-*.txt"))
+			assert.Len(t, diffFiles, 1)
+
+			cmd.Dir = t.TempDir()
+			cmd.Env = append(os.Environ(), env...)
+			if out, err := cmd.CombinedOutput(); err != nil {
+				t.Fatalf("failed to run custom command: %v, output: %s", err, out)
+			}
+			got, err := ioutil.ReadFile(filepath.Join(cmd.Dir, "out.txt"))
+			if err != nil {
+				t.Fatalf("failed to read output: %v", err)
+			}
+			assert.Equal(t, tt.want, string(got))
+		})
+	}
+}
+
+func TestBuildArgsTemplate(t *testing.T) {
+	defer delete(global.FrameworkDiscoveryArgs, "custom")
+	global.FrameworkDiscoveryArgs["custom"] = global.DiscoveryArgs{
+		Command:   global.ArgTemplate{"discover"},
+		Diff:      global.ArgTemplate{"--changed=%s"},
+		EmptyDiff: global.ArgTemplate{"--no-changes"},
+		Config:    global.ArgTemplate{"-c", "%s"},
+		Pattern:   global.ArgTemplate{"--spec=%s"},
+	}
+	payload := &core.Payload{EventType: core.EventPush, TasFileName: ".tas.yml", ParentCommitCoverageExists: true}
+	target := []string{"./test/**/*.spec.js"}
+
+	tests := []struct {
+		name      string
+		framework string
+		diff      map[string]int
+		want      []string
+	}{
+		{"jest", "jest", map[string]int{"src/a.js": core.FileModified},
+			[]string{"--command", "discover", "--diff", "src/a.js", "--config", "jest.config.js", "--pattern", "./test/**/*.spec.js"}},
+		{"custom", "custom", map[string]int{"src/a.js": core.FileModified},
+			[]string{"discover", "--changed=src/a.js", "-c", "jest.config.js", "--spec=./test/**/*.spec.js"}},
+		{"unregistered framework", "vitest", map[string]int{"src/a.js": core.FileModified},
+			[]string{"--command", "discover", "--diff", "src/a.js", "--config", "jest.config.js", "--pattern", "./test/**/*.spec.js"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tasConfig := &core.TASConfig{SmartRun: true, Framework: tt.framework, ConfigFile: "jest.config.js"}
+			tds := newTestDiscoveryService(t, &config.NucleusConfig{})
+			args, err := tds.buildArgs(tasConfig, payload, target, tt.diff, core.NewWarnings(tds.logger))
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, args)
+		})
+	}
+
+	// an empty pull request diff renders the template without a value
+	tasConfig := &core.TASConfig{SmartRun: true, Framework: "custom"}
+	prPayload := &core.Payload{EventType: core.EventPullRequest, TasFileName: ".tas.yml", ParentCommitCoverageExists: true}
+	tds := newTestDiscoveryService(t, &config.NucleusConfig{EmptyDiffFallback: global.EmptyDiffDiscoverNone})
+	args, err := tds.buildArgs(tasConfig, prPayload, target, map[string]int{}, core.NewWarnings(tds.logger))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"discover", "--no-changes", "--spec=./test/**/*.spec.js"}, args)
+}
+
+func TestWorkDirDiff(t *testing.T) {
+	workDir := t.TempDir()
+	tasConfig := &core.TASConfig{SmartRun: true}
+	payload := &core.Payload{
+		EventType:                  core.EventPush,
+		TasFileName:                ".tas.yml",
+		ParentCommitCoverageExists: true,
+	}
+	diff := map[string]int{"src/b.js": core.FileModified, "src/a.js": core.FileAdded, "src/c.js": core.FileRemoved}
+
+	tds := newTestDiscoveryService(t, &config.NucleusConfig{WorkDir: workDir})
+	if _, err := tds.buildArgs(tasConfig, payload, []string{"./test/**/*.spec.js"}, diff, core.NewWarnings(tds.logger)); err != nil {
+		t.Fatalf("buildArgs() error = %v", err)
+	}
+	tds.writeWorkFile(global.WorkDiscoveryCommandFile, discoveryCommand{Framework: "jest", Args: []string{"jest-runner", "--command", "discover"}})
+
+	content, err := ioutil.ReadFile(filepath.Join(workDir, global.WorkDiffFile))
+	if err != nil {
+		t.Fatalf("failed to read the resolved diff: %v", err)
+	}
+	var got resolvedDiff
+	assert.Nil(t, json.Unmarshal(content, &got))
+	assert.Equal(t, resolvedDiff{DiscoverAll: false, ChangedFiles: []string{"src/a.js", "src/b.js"}, Diff: diff}, got)
+	assert.FileExists(t, filepath.Join(workDir, global.WorkDiscoveryCommandFile))
+
+	// only the records are left in the work directory
+	entries, err := ioutil.ReadDir(filepath.Join(workDir, "discovery"))
+	assert.Nil(t, err)
+	assert.Len(t, entries, 2)
+}
+
+// fakeExecutionManager leaves the priority of the discovery command unchanged and starts it as is
+type fakeExecutionManager struct {
+	core.ExecutionManager
+}
+
+func (f *fakeExecutionManager) Deprioritize(cmd *exec.Cmd) error {
+	return nil
+}
+
+func (f *fakeExecutionManager) StartProcessGroup(ctx context.Context, cmd *exec.Cmd) (func() error, error) {
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return cmd.Wait, nil
+}
+
+func TestRunDiscoveryWarmup(t *testing.T) {
+	tests := []struct {
+		name    string
+		warmup  string
+		want    string
+		wantErr error
+	}{
+		{"without warmup", "", "discover\n", nil},
+		{"warmup before discovery", "echo warmup $STAGE_ENV >> steps", "warmup discovery-env\ndiscover\n", nil},
+		{"failing warmup", "echo warmup >> steps; exit 1", "warmup\n", errs.ErrDiscoveryWarmup},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			tds := newTestDiscoveryService(t, &config.NucleusConfig{})
+			tds.execManager = &fakeExecutionManager{}
+			cmd := exec.Command("/bin/bash", "-c", "echo discover >> steps")
+			cmd.Dir = dir
+			cmd.Env = append(os.Environ(), "STAGE_ENV=discovery-env")
+
+			err := tds.runDiscovery(context.TODO(), cmd, tt.warmup)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.Nil(t, err)
+			}
+			// the warmup runs in the directory and the environment of the discovery, right before it
+			steps, err := ioutil.ReadFile(filepath.Join(dir, "steps"))
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, string(steps))
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package testexecutionservice
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"os"
+	"path/filepath"
+
+	"github.com/LambdaTest/synapse/pkg/core"
+	"github.com/LambdaTest/synapse/pkg/errs"
+	"github.com/LambdaTest/synapse/pkg/fileutils"
+)
+
+const defaultArtifactMimeType = "application/octet-stream"
+
+// uploadArtifacts uploads the files matching the glob patterns, relative to root, under blobPath.
+// Matched directories are uploaded recursively. Patterns without matches are skipped with a warning,
+// while patterns or matches resolving outside of root fail the collection.
+func (tes *testExecutionService) uploadArtifacts(ctx context.Context,
+	root string,
+	patterns []string,
+	blobPath string,
+	warns *core.Warnings) error {
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return err
+	}
+	for _, pattern := range patterns {
+		if filepath.IsAbs(pattern) || !fileutils.IsWithin(root, filepath.Join(root, pattern)) {
+			return fmt.Errorf("%w: %s", errs.ErrUnsafeArtifactPath, pattern)
+		}
+		matches, err := filepath.Glob(filepath.Join(root, pattern))
+		if err != nil {
+			return err
+		}
+		if len(matches) == 0 {
+			warns.Addf("No artifacts found for pattern %s", pattern)
+			continue
+		}
+		for _, match := range matches {
+			if err := tes.uploadArtifact(ctx, root, realRoot, match, blobPath); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// uploadArtifact uploads the file at path, or all the files inside it if path is a directory
+func (tes *testExecutionService) uploadArtifact(ctx context.Context, root, realRoot, path, blobPath string) error {
+	return filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		// symlinks to files are followed, as long as they point inside the repo
+		realPath, err := filepath.EvalSymlinks(file)
+		if err != nil {
+			return err
+		}
+		if !fileutils.IsWithin(realRoot, realPath) {
+			return fmt.Errorf("%w: %s", errs.ErrUnsafeArtifactPath, file)
+		}
+		if info, err = os.Stat(realPath); err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, file)
+		if err != nil {
+			return err
+		}
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		mimeType := mime.TypeByExtension(filepath.Ext(file))
+		if mimeType == "" {
+			mimeType = defaultArtifactMimeType
+		}
+		if _, err := tes.azureClient.Create(ctx, fmt.Sprintf("%s/%s", blobPath, filepath.ToSlash(rel)), f, mimeType); err != nil {
+			return err
+		}
+		tes.logger.Debugf("uploaded artifact %s", rel)
+		return nil
+	})
+}
@@ -0,0 +1,91 @@
+package testexecutionservice
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/LambdaTest/synapse/pkg/core"
+	"github.com/LambdaTest/synapse/pkg/errs"
+	"github.com/LambdaTest/synapse/pkg/lumber"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeAzureClient struct {
+	core.AzureClient
+	blobs map[string]string
+}
+
+func (f *fakeAzureClient) Create(ctx context.Context, path string, reader io.Reader, mimeType string) (string, error) {
+	content, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return "", err
+	}
+	f.blobs[path] = string(content)
+	return path, nil
+}
+
+func TestUploadArtifacts(t *testing.T) {
+	logger, err := lumber.NewLogger(lumber.LoggingConfig{EnableConsole: true}, true, lumber.InstanceZapLogger)
+	if err != nil {
+		log.Fatalf("Could not instantiate logger %s", err.Error())
+	}
+	root := t.TempDir()
+	outside := t.TempDir()
+	files := map[string]string{
+		"screenshots/login.png":  "png",
+		"screenshots/a/home.png": "home",
+		"logs/e2e.log":           "log",
+		"logs/e2e.txt":           "txt",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+	}
+	if err := ioutil.WriteFile(filepath.Join(outside, "secret"), []byte("secret"), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	if err := os.Symlink(filepath.Join(outside, "secret"), filepath.Join(root, "leak.log")); err != nil {
+		t.Fatalf("failed to create symlink: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		patterns     []string
+		want         map[string]string
+		wantErr      error
+		wantWarnings []string
+	}{
+		{"files and directories", []string{"screenshots", "logs/*.log", "missing/*.png"}, map[string]string{
+			"build/artifacts/screenshots/login.png":  "png",
+			"build/artifacts/screenshots/a/home.png": "home",
+			"build/artifacts/logs/e2e.log":           "log",
+		}, nil, []string{"No artifacts found for pattern missing/*.png"}},
+		{"parent directory", []string{"../*"}, map[string]string{}, errs.ErrUnsafeArtifactPath, nil},
+		{"absolute path", []string{outside}, map[string]string{}, errs.ErrUnsafeArtifactPath, nil},
+		{"symlink outside", []string{"*.log"}, map[string]string{}, errs.ErrUnsafeArtifactPath, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			azureClient := &fakeAzureClient{blobs: map[string]string{}}
+			tes := &testExecutionService{logger: logger, azureClient: azureClient}
+			warns := core.NewWarnings(logger)
+			err := tes.uploadArtifacts(context.TODO(), root, tt.patterns, "build/artifacts", warns)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("uploadArtifacts() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			assert.Equal(t, tt.want, azureClient.blobs)
+			assert.Equal(t, tt.wantWarnings, warns.List())
+		})
+	}
+}
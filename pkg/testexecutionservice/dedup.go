@@ -0,0 +1,62 @@
+package testexecutionservice
+
+import (
+	"fmt"
+
+	"github.com/LambdaTest/synapse/pkg/core"
+	"github.com/LambdaTest/synapse/pkg/global"
+)
+
+// validateRetryResultRule checks that rule is one of the retry result rules
+func validateRetryResultRule(rule string) error {
+	if rule != global.RetryResultLast && rule != global.RetryResultAnyPass {
+		return fmt.Errorf("invalid retry result rule %q", rule)
+	}
+	return nil
+}
+
+// dedupTestResults collapses the attempts of each retried test into a single result which records the number
+// of attempts. With the last attempt rule the result of the attempt with the highest retry wins, with the
+// any-pass rule a passed attempt wins over the others. The results keep the order of their first attempt,
+// results without a test ID are kept as is.
+func dedupTestResults(results []core.TestPayload, rule string) ([]core.TestPayload, error) {
+	if err := validateRetryResultRule(rule); err != nil {
+		return nil, err
+	}
+	deduped := make([]core.TestPayload, 0, len(results))
+	index := make(map[string]int, len(results))
+	for _, result := range results {
+		if result.TestID == "" {
+			deduped = append(deduped, result)
+			continue
+		}
+		i, ok := index[result.TestID]
+		if !ok {
+			result.Attempts = 1
+			index[result.TestID] = len(deduped)
+			deduped = append(deduped, result)
+			continue
+		}
+		current := &deduped[i]
+		attempts := current.Attempts + 1
+		if wins(current, &result, rule) {
+			*current = result
+		}
+		current.Attempts = attempts
+	}
+	return deduped, nil
+}
+
+// wins reports whether the attempt replaces the current result of the test
+func wins(current, attempt *core.TestPayload, rule string) bool {
+	if rule == global.RetryResultAnyPass {
+		passed := string(core.Passed)
+		if current.Status == passed && attempt.Status != passed {
+			return false
+		}
+		if attempt.Status == passed && current.Status != passed {
+			return true
+		}
+	}
+	return attempt.CurrentRetry >= current.CurrentRetry
+}
@@ -0,0 +1,63 @@
+package testexecutionservice
+
+import (
+	"testing"
+
+	"github.com/LambdaTest/synapse/config"
+	"github.com/LambdaTest/synapse/pkg/core"
+	"github.com/LambdaTest/synapse/pkg/global"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDedupTestResults(t *testing.T) {
+	results := []core.TestPayload{
+		{TestID: "a", Status: "failed", CurrentRetry: 0},
+		{TestID: "b", Status: "passed", CurrentRetry: 0},
+		{TestID: "a", Status: "passed", CurrentRetry: 1},
+		{TestID: "c", Status: "passed", CurrentRetry: 0},
+		{TestID: "a", Status: "failed", CurrentRetry: 2},
+		{TestID: "", Status: "failed"},
+		{TestID: "", Status: "failed"},
+		{TestID: "c", Status: "failed", CurrentRetry: 1},
+	}
+	tests := []struct {
+		name    string
+		rule    string
+		want    []core.TestPayload
+		wantErr bool
+	}{
+		{"last attempt wins", global.RetryResultLast, []core.TestPayload{
+			{TestID: "a", Status: "failed", CurrentRetry: 2, Attempts: 3},
+			{TestID: "b", Status: "passed", CurrentRetry: 0, Attempts: 1},
+			{TestID: "c", Status: "failed", CurrentRetry: 1, Attempts: 2},
+			{TestID: "", Status: "failed"},
+			{TestID: "", Status: "failed"},
+		}, false},
+		{"any pass wins", global.RetryResultAnyPass, []core.TestPayload{
+			{TestID: "a", Status: "passed", CurrentRetry: 1, Attempts: 3},
+			{TestID: "b", Status: "passed", CurrentRetry: 0, Attempts: 1},
+			{TestID: "c", Status: "passed", CurrentRetry: 0, Attempts: 2},
+			{TestID: "", Status: "failed"},
+			{TestID: "", Status: "failed"},
+		}, false},
+		{"invalid rule", "first", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := dedupTestResults(results, tt.rule)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("dedupTestResults() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestNewTestExecutionServiceRetryResultRule(t *testing.T) {
+	for _, rule := range []string{global.RetryResultLast, global.RetryResultAnyPass} {
+		_, err := NewTestExecutionService(nil, nil, nil, &config.NucleusConfig{RetryResultRule: rule}, nil)
+		assert.Nil(t, err)
+	}
+	_, err := NewTestExecutionService(nil, nil, nil, &config.NucleusConfig{RetryResultRule: "first"}, nil)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,49 @@
+package testexecutionservice
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"runtime"
+
+	"github.com/LambdaTest/synapse/pkg/core"
+	"github.com/LambdaTest/synapse/pkg/logstream"
+)
+
+// environment returns the snapshot of the environment the tests are executed in. The env is the
+// env map of the configuration, its values are masked as they would be in the logs.
+func (tes *testExecutionService) environment(ctx context.Context,
+	framework string,
+	envMap, secretData map[string]string) *core.Environment {
+	env := make(map[string]string, len(envMap))
+	for k, v := range envMap {
+		env[k] = logstream.MaskString(v, secretData)
+	}
+	return &core.Environment{
+		Env:              env,
+		NodeVersion:      tes.runnerVersion(ctx, "node"),
+		Framework:        framework,
+		FrameworkVersion: tes.frameworkVersion(framework),
+		OS:               runtime.GOOS,
+		Arch:             runtime.GOARCH,
+	}
+}
+
+// frameworkVersion returns the version of the framework package installed in the repo, or an empty
+// version if it cannot be read
+func (tes *testExecutionService) frameworkVersion(framework string) string {
+	content, err := ioutil.ReadFile(filepath.Join(runnerDir, "node_modules", framework, "package.json"))
+	if err != nil {
+		tes.logger.Warnf("failed to read the package of framework %s, error: %v", framework, err)
+		return ""
+	}
+	var pkg struct {
+		Version string `json:"version"`
+	}
+	if err := json.Unmarshal(content, &pkg); err != nil {
+		tes.logger.Warnf("failed to parse the package of framework %s, error: %v", framework, err)
+		return ""
+	}
+	return pkg.Version
+}
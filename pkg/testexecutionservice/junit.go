@@ -0,0 +1,135 @@
+package testexecutionservice
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/LambdaTest/synapse/pkg/core"
+)
+
+const suiteNameSeparator = " > "
+
+// junitTestSuites is the root element of a JUnit XML report
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+// junitTestSuite groups the test cases belonging to the same suite
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+// junitTestCase represents a single test in the JUnit XML report
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	File      string        `xml:"file,attr,omitempty"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *struct{}     `xml:"skipped,omitempty"`
+}
+
+// junitFailure holds the failure details of a failed test
+type junitFailure struct {
+	Message string `xml:"message,attr,omitempty"`
+	Content string `xml:",chardata"`
+}
+
+// writeJUnitReport serializes the test results as JUnit XML and writes them to path.
+func writeJUnitReport(path string, testResults []core.TestPayload) error {
+	rawBytes, err := marshalJUnitReport(testResults)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, rawBytes, 0644)
+}
+
+// marshalJUnitReport converts the test results into a JUnit XML document.
+// Tests are grouped into testsuites by their (nested) suite names, falling back
+// to the test file when a test does not belong to any suite.
+func marshalJUnitReport(testResults []core.TestPayload) ([]byte, error) {
+	report := junitTestSuites{}
+	suiteIndex := make(map[string]int)
+	suiteDuration := make(map[string]int)
+	totalDuration := 0
+
+	for i := range testResults {
+		result := &testResults[i]
+		suiteName := strings.Join(result.Suites, suiteNameSeparator)
+		if suiteName == "" {
+			suiteName = result.FilePath
+		}
+		idx, ok := suiteIndex[suiteName]
+		if !ok {
+			idx = len(report.Suites)
+			suiteIndex[suiteName] = idx
+			report.Suites = append(report.Suites, junitTestSuite{Name: suiteName})
+		}
+		suite := &report.Suites[idx]
+
+		testCase := junitTestCase{
+			Name:      result.Title,
+			ClassName: suiteName,
+			File:      result.FilePath,
+			Time:      formatJUnitDuration(result.Duration),
+		}
+		if testCase.Name == "" {
+			testCase.Name = result.Name
+		}
+		switch {
+		case result.Status == string(core.Failed):
+			testCase.Failure = &junitFailure{Message: firstLine(result.Detail), Content: result.Detail}
+			suite.Failures++
+			report.Failures++
+		case result.Blocklisted || result.Status == "skipped" || result.Status == "pending" || result.Status == "blocklisted":
+			testCase.Skipped = &struct{}{}
+			suite.Skipped++
+			report.Skipped++
+		}
+		suite.TestCases = append(suite.TestCases, testCase)
+		suite.Tests++
+		report.Tests++
+		suiteDuration[suiteName] += result.Duration
+		totalDuration += result.Duration
+	}
+
+	for i := range report.Suites {
+		report.Suites[i].Time = formatJUnitDuration(suiteDuration[report.Suites[i].Name])
+	}
+	report.Time = formatJUnitDuration(totalDuration)
+
+	rawBytes, err := xml.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(rawBytes, '\n')...), nil
+}
+
+// formatJUnitDuration converts the duration in milliseconds to seconds
+func formatJUnitDuration(durationMs int) string {
+	return fmt.Sprintf("%.3f", float64(durationMs)/1000)
+}
+
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
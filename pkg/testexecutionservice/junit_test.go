@@ -0,0 +1,52 @@
+package testexecutionservice
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/LambdaTest/synapse/pkg/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarshalJUnitReport(t *testing.T) {
+	testResults := []core.TestPayload{
+		{
+			Title:    "adds two numbers",
+			Suites:   []string{"math", "add"},
+			FilePath: "test/math.spec.js",
+			Duration: 12,
+			Status:   "passed",
+		},
+		{
+			Title:    "subtracts two numbers",
+			Suites:   []string{"math", "subtract"},
+			FilePath: "test/math.spec.js",
+			Duration: 1500,
+			Status:   "failed",
+			Detail:   "AssertionError: expected 1 to equal 2\n    at Context.<anonymous> (test/math.spec.js:10:12)",
+		},
+		{
+			Title:    "handles negative numbers",
+			Suites:   []string{"math", "add"},
+			FilePath: "test/math.spec.js",
+			Duration: 3,
+			Status:   "passed",
+		},
+		{
+			Title:       "is blocklisted",
+			FilePath:    "test/api.spec.js",
+			Status:      "skipped",
+			Blocklisted: true,
+		},
+	}
+
+	got, err := marshalJUnitReport(testResults)
+	if err != nil {
+		t.Fatalf("failed to marshal junit report: %v", err)
+	}
+	want, err := ioutil.ReadFile("testdata/junit.golden.xml")
+	if err != nil {
+		t.Fatalf("failed to read golden file: %v", err)
+	}
+	assert.Equal(t, string(want), string(got))
+}
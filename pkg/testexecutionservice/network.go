@@ -0,0 +1,53 @@
+package testexecutionservice
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/LambdaTest/synapse/pkg/core"
+)
+
+// flagNetworkAccess fails the test which was running when a network access was refused, naming the
+// refused hosts in its detail. The attempts are matched to the tests by time only, so an attempt made
+// while several tests were running, e.g. by parallel workers, is not attributed to any of them. It
+// returns the attempts made outside of a single test, including the ones made in the hooks.
+func flagNetworkAccess(results []core.TestPayload, attempts []core.NetworkAttempt) (unattributed []core.NetworkAttempt) {
+	hosts := make(map[int]map[string]struct{})
+	for _, attempt := range attempts {
+		running := -1
+		for i := range results {
+			if results[i].StartTime.IsZero() || attempt.Time.Before(results[i].StartTime) || attempt.Time.After(results[i].EndTime) {
+				continue
+			}
+			if running != -1 {
+				running = -1
+				break
+			}
+			running = i
+		}
+		if running == -1 {
+			unattributed = append(unattributed, attempt)
+			continue
+		}
+		if hosts[running] == nil {
+			hosts[running] = make(map[string]struct{})
+		}
+		hosts[running][attempt.Host] = struct{}{}
+	}
+	for i, set := range hosts {
+		names := make([]string, 0, len(set))
+		for host := range set {
+			names = append(names, host)
+		}
+		sort.Strings(names)
+		result := &results[i]
+		result.Status = string(core.Failed)
+		msg := fmt.Sprintf("network access to %s was blocked", strings.Join(names, ", "))
+		if result.Detail != "" {
+			msg += "\n" + result.Detail
+		}
+		result.Detail = msg
+	}
+	return unattributed
+}
@@ -0,0 +1,95 @@
+package testexecutionservice
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/LambdaTest/synapse/config"
+	"github.com/LambdaTest/synapse/pkg/command"
+	"github.com/LambdaTest/synapse/pkg/core"
+	"github.com/LambdaTest/synapse/pkg/lumber"
+	"github.com/stretchr/testify/assert"
+)
+
+// proxyClient returns a client using the proxy set in the environment of the guard
+func proxyClient(t *testing.T, env []string) *http.Client {
+	for _, kv := range env {
+		if value := strings.TrimPrefix(kv, "HTTP_PROXY="); value != kv {
+			proxyURL, err := url.Parse(value)
+			if err != nil {
+				t.Fatalf("failed to parse the proxy url %s, error: %v", value, err)
+			}
+			return &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(proxyURL)}}
+		}
+	}
+	t.Fatalf("HTTP_PROXY is not set in %v", env)
+	return nil
+}
+
+func TestFlagNetworkAccess(t *testing.T) {
+	logger, err := lumber.NewLogger(lumber.LoggingConfig{EnableConsole: true}, true, lumber.InstanceZapLogger)
+	if err != nil {
+		t.Fatalf("Could not instantiate logger %s", err.Error())
+	}
+	guard, err := command.NewExecutionManager(nil, nil, &config.NucleusConfig{}, logger).BlockNetwork()
+	if err != nil {
+		t.Fatalf("BlockNetwork() error = %v", err)
+	}
+	defer guard.Close()
+	client := proxyClient(t, guard.Env())
+
+	// the second test calls the network, the others do not
+	var results []core.TestPayload
+	for _, id := range []string{"offline", "online", "after"} {
+		result := core.TestPayload{TestID: id, Status: string(core.Passed), StartTime: time.Now()}
+		if id == "online" {
+			resp, err := client.Get("http://example.com/api")
+			if err != nil {
+				t.Fatalf("request through the guard error = %v", err)
+			}
+			resp.Body.Close()
+			assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		}
+		result.EndTime = time.Now()
+		results = append(results, result)
+	}
+	// a call outside of the tests is not attributed to any of them
+	resp, err := client.Get("http://registry.npmjs.org/")
+	if err != nil {
+		t.Fatalf("request through the guard error = %v", err)
+	}
+	resp.Body.Close()
+
+	unattributed := flagNetworkAccess(results, guard.Attempts())
+	assert.Equal(t, string(core.Passed), results[0].Status)
+	assert.Equal(t, string(core.Failed), results[1].Status)
+	assert.Equal(t, "network access to example.com was blocked", results[1].Detail)
+	assert.Equal(t, string(core.Passed), results[2].Status)
+	if assert.Len(t, unattributed, 1) {
+		assert.Equal(t, "registry.npmjs.org", unattributed[0].Host)
+	}
+}
+
+func TestFlagNetworkAccessOverlapping(t *testing.T) {
+	start := time.Now()
+	results := []core.TestPayload{
+		{TestID: "first", Status: string(core.Passed), StartTime: start, EndTime: start.Add(2 * time.Second)},
+		{TestID: "second", Status: string(core.Passed), StartTime: start.Add(time.Second), EndTime: start.Add(3 * time.Second)},
+	}
+	attempts := []core.NetworkAttempt{
+		{Host: "example.com", Time: start.Add(500 * time.Millisecond)},
+		{Host: "registry.npmjs.org", Time: start.Add(1500 * time.Millisecond)},
+	}
+
+	unattributed := flagNetworkAccess(results, attempts)
+	assert.Equal(t, string(core.Failed), results[0].Status)
+	assert.Equal(t, "network access to example.com was blocked", results[0].Detail)
+	// the second attempt was made while both tests were running
+	assert.Equal(t, string(core.Passed), results[1].Status)
+	if assert.Len(t, unattributed, 1) {
+		assert.Equal(t, "registry.npmjs.org", unattributed[0].Host)
+	}
+}
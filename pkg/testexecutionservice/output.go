@@ -0,0 +1,31 @@
+package testexecutionservice
+
+import (
+	"bytes"
+
+	"github.com/LambdaTest/synapse/pkg/core"
+	"github.com/LambdaTest/synapse/pkg/global"
+	"github.com/LambdaTest/synapse/pkg/logstream"
+)
+
+// attachTestOutput keeps the output reported by the runner on the failed tests only, masked and limited
+// to global.MaxTestOutputSize. The output is dropped from all the results if capture is disabled.
+func attachTestOutput(results []core.TestPayload, capture bool, secretData map[string]string) error {
+	for i := range results {
+		result := &results[i]
+		if !capture || result.Status != string(core.Failed) || result.Output == "" {
+			result.Output = ""
+			continue
+		}
+		var buf bytes.Buffer
+		limiter := logstream.NewLimiter(&buf, global.MaxTestOutputSize)
+		if _, err := logstream.NewMasker(limiter, secretData).Write([]byte(result.Output)); err != nil {
+			return err
+		}
+		if err := limiter.Close(); err != nil {
+			return err
+		}
+		result.Output = buf.String()
+	}
+	return nil
+}
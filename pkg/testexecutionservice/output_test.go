@@ -0,0 +1,38 @@
+package testexecutionservice
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/LambdaTest/synapse/pkg/core"
+	"github.com/LambdaTest/synapse/pkg/global"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttachTestOutput(t *testing.T) {
+	newResults := func() []core.TestPayload {
+		return []core.TestPayload{
+			{TestID: "a", Status: "failed", Output: "expected 1 to equal 2, token s3cr3t"},
+			{TestID: "b", Status: "passed", Output: "all good"},
+			{TestID: "c", Status: "failed", Output: strings.Repeat("x", global.MaxTestOutputSize*2)},
+		}
+	}
+	secretData := map[string]string{"TOKEN": "s3cr3t"}
+
+	results := newResults()
+	if err := attachTestOutput(results, true, secretData); err != nil {
+		t.Fatalf("attachTestOutput() error = %v", err)
+	}
+	assert.Equal(t, "expected 1 to equal 2, token ****************", results[0].Output)
+	assert.Equal(t, "", results[1].Output)
+	assert.Contains(t, results[2].Output, "bytes of output elided")
+	assert.Less(t, len(results[2].Output), global.MaxTestOutputSize+100)
+
+	results = newResults()
+	if err := attachTestOutput(results, false, secretData); err != nil {
+		t.Fatalf("attachTestOutput() error = %v", err)
+	}
+	for _, result := range results {
+		assert.Equal(t, "", result.Output)
+	}
+}
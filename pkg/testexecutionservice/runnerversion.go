@@ -0,0 +1,37 @@
+package testexecutionservice
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+
+	"github.com/LambdaTest/synapse/pkg/global"
+)
+
+// runnerDir is the directory the runner version is looked up from
+var runnerDir = global.RepoDir
+
+// runnerVersion returns the version reported by `<runner> --version`. The lookup is done
+// once per runner, failures are cached as well and result in an empty version.
+func (tes *testExecutionService) runnerVersion(ctx context.Context, runner string) string {
+	tes.versionMu.Lock()
+	defer tes.versionMu.Unlock()
+	if version, ok := tes.runnerVersions[runner]; ok {
+		return version
+	}
+
+	cmd := exec.CommandContext(ctx, runner, "--version")
+	cmd.Dir = runnerDir
+	out, err := cmd.Output()
+	version := ""
+	if err != nil {
+		tes.logger.Warnf("failed to get version of runner %s, error: %v", runner, err)
+	} else {
+		version = strings.TrimSpace(string(out))
+	}
+	if tes.runnerVersions == nil {
+		tes.runnerVersions = make(map[string]string)
+	}
+	tes.runnerVersions[runner] = version
+	return version
+}
@@ -0,0 +1,41 @@
+package testexecutionservice
+
+import (
+	"context"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"testing"
+
+	"github.com/LambdaTest/synapse/pkg/lumber"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunnerVersion(t *testing.T) {
+	logger, err := lumber.NewLogger(lumber.LoggingConfig{EnableConsole: true}, true, lumber.InstanceZapLogger)
+	if err != nil {
+		log.Fatalf("Could not instantiate logger %s", err.Error())
+	}
+	dir := t.TempDir()
+	defer func(dir string) { runnerDir = dir }(runnerDir)
+	runnerDir = dir
+
+	// the stub runner records each invocation, so that the cached lookups can be counted
+	runner := filepath.Join(dir, "mocha-runner")
+	script := "#!/bin/sh\necho x >> calls\necho \"  1.2.3\"\n"
+	if err := ioutil.WriteFile(runner, []byte(script), 0755); err != nil {
+		t.Fatalf("failed to write stub runner: %v", err)
+	}
+
+	tes := &testExecutionService{logger: logger}
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, "1.2.3", tes.runnerVersion(context.TODO(), runner))
+	}
+	calls, err := ioutil.ReadFile(filepath.Join(dir, "calls"))
+	if err != nil {
+		t.Fatalf("failed to read calls: %v", err)
+	}
+	assert.Equal(t, "x\n", string(calls))
+
+	assert.Equal(t, "", tes.runnerVersion(context.TODO(), filepath.Join(dir, "missing-runner")))
+}
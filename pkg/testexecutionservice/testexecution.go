@@ -3,39 +3,60 @@ package testexecutionservice
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"math"
+	"math/rand"
 	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/LambdaTest/synapse/config"
 	"github.com/LambdaTest/synapse/pkg/core"
+	"github.com/LambdaTest/synapse/pkg/errs"
+	"github.com/LambdaTest/synapse/pkg/fileutils"
 	"github.com/LambdaTest/synapse/pkg/global"
 	"github.com/LambdaTest/synapse/pkg/logstream"
 	"github.com/LambdaTest/synapse/pkg/lumber"
+	"github.com/LambdaTest/synapse/pkg/service/coverage"
 	"github.com/LambdaTest/synapse/pkg/service/teststats"
+	"github.com/LambdaTest/synapse/pkg/utils"
 )
 
 const locatorFile = "locators"
 
 type testExecutionService struct {
+	cfg         *config.NucleusConfig
 	logger      lumber.Logger
 	azureClient core.AzureClient
 	ts          *teststats.ProcStats
 	execManager core.ExecutionManager
+	// runnerVersions caches the version of each framework runner
+	runnerVersions map[string]string
+	versionMu      sync.Mutex
 }
 
 // NewTestExecutionService creates and returns a new TestExecutionService instance
 func NewTestExecutionService(execManager core.ExecutionManager,
 	azureClient core.AzureClient,
 	ts *teststats.ProcStats,
-	logger lumber.Logger) core.TestExecutionService {
+	cfg *config.NucleusConfig,
+	logger lumber.Logger) (core.TestExecutionService, error) {
+	// the retry result rule is applied after the tests have run, so it is validated upfront
+	if err := validateRetryResultRule(cfg.RetryResultRule); err != nil {
+		return nil, err
+	}
 	return &testExecutionService{execManager: execManager,
 		azureClient: azureClient,
 		ts:          ts,
-		logger:      logger}
+		cfg:         cfg,
+		logger:      logger}, nil
 }
 
 // Run executes the test files
@@ -43,7 +64,8 @@ func (tes *testExecutionService) Run(ctx context.Context,
 	tasConfig *core.TASConfig,
 	payload *core.Payload,
 	coverageDir string,
-	secretData map[string]string) (*core.ExecutionResult, error) {
+	secretData map[string]string,
+	warns *core.Warnings) (*core.ExecutionResult, error) {
 
 	azureReader, azureWriter := io.Pipe()
 	defer azureWriter.Close()
@@ -52,7 +74,11 @@ func (tes *testExecutionService) Run(ctx context.Context,
 	logWriter := lumber.NewWriter(tes.logger)
 	defer logWriter.Close()
 	multiWriter := io.MultiWriter(logWriter, azureWriter)
-	maskWriter := logstream.NewMasker(multiWriter, secretData)
+	limitWriter := logstream.NewLimiter(multiWriter, tes.cfg.CommandLogLimit*global.MB)
+	defer limitWriter.Close()
+	// the tail of the output classifies the failure of the runner
+	outputTail := logstream.NewTail(global.FailureOutputTail)
+	maskWriter := logstream.NewMasker(io.MultiWriter(limitWriter, outputTail), secretData)
 
 	var target []string
 	var envMap map[string]string
@@ -63,6 +89,17 @@ func (tes *testExecutionService) Run(ctx context.Context,
 		target = tasConfig.Postmerge.Patterns
 		envMap = tasConfig.Postmerge.EnvMap
 	}
+	if !tasConfig.SkipFrameworkEnv {
+		envMap = utils.FrameworkEnv(tasConfig.Framework, envMap)
+	}
+	if len(tasConfig.CleanPaths) > 0 {
+		tes.logger.Debugf("Removing paths %+v before executing tests", tasConfig.CleanPaths)
+		if err := fileutils.RemoveWithin(global.RepoDir, tasConfig.CleanPaths); err != nil {
+			tes.logger.Errorf("failed to clean paths, error: %v", err)
+			return nil, err
+		}
+	}
+
 	var args []string
 	args = []string{global.FrameworkRunnerMap[tasConfig.Framework], "--command", "execute"}
 	if tasConfig.ConfigFile != "" {
@@ -71,7 +108,30 @@ func (tes *testExecutionService) Run(ctx context.Context,
 	for _, pattern := range target {
 		args = append(args, "--pattern", pattern)
 	}
+	// the execution options are passed to the runner through env vars, it maps them to the framework options
+	var runnerEnv []string
+	if tasConfig.ExecConcurrency > 0 {
+		concurrencyEnv := execConcurrencyEnv(tasConfig.Framework, tasConfig.ExecConcurrency)
+		if len(concurrencyEnv) == 0 {
+			warns.Addf("execConcurrency is not supported by framework %s, ignoring it", tasConfig.Framework)
+		}
+		runnerEnv = append(runnerEnv, concurrencyEnv...)
+	}
+	var seed int64
+	if tasConfig.ExecutionOrder != nil {
+		orderEnv, orderSeed, ok := executionOrderEnv(tasConfig.Framework, tasConfig.ExecutionOrder)
+		if !ok {
+			warns.Addf("%s execution order is not supported by framework %s, running tests in declared order",
+				tasConfig.ExecutionOrder.Strategy, tasConfig.Framework)
+		}
+		if orderSeed != 0 {
+			tes.logger.Infof("Executing tests in random order with seed %d", orderSeed)
+		}
+		runnerEnv = append(runnerEnv, orderEnv...)
+		seed = orderSeed
+	}
 
+	var assigned assignment
 	if payload.LocatorAddress != "" {
 		locatorFile, err := tes.GetLocatorsFile(ctx, payload.LocatorAddress)
 		if err != nil {
@@ -79,6 +139,7 @@ func (tes *testExecutionService) Run(ctx context.Context,
 			return nil, err
 		}
 		args = append(args, "--locator-file", locatorFile)
+		assigned.LocatorFile = locatorFile
 	}
 	// use locators only if there is no locator address
 	if payload.Locators != "" && payload.LocatorAddress == "" {
@@ -86,9 +147,14 @@ func (tes *testExecutionService) Run(ctx context.Context,
 		for _, locator := range locators {
 			if locator != "" {
 				args = append(args, "--locator", locator)
+				assigned.Locators = append(assigned.Locators, locator)
 			}
 		}
 	}
+	assigned.Args = args
+	tes.writeWorkFile(global.WorkAssignmentFile, assigned)
+	runnerVersion := tes.runnerVersion(ctx, args[0])
+	tes.logger.Debugf("Executing tests with %s runner version %s", tasConfig.Framework, runnerVersion)
 	collectCoverage := payload.CollectCoverage
 	testResults := make([]core.TestPayload, 0)
 	testSuiteResults := make([]core.TestSuitePayload, 0)
@@ -99,15 +165,26 @@ func (tes *testExecutionService) Run(ctx context.Context,
 		tes.logger.Errorf("failed to parsed env variables, error: %v", err)
 		return nil, err
 	}
+	envVars = append(envVars, runnerEnv...)
+	var guard core.NetworkGuard
+	if tasConfig.BlockNetwork {
+		guard, err = tes.execManager.BlockNetwork()
+		if err != nil {
+			tes.logger.Errorf("failed to block the network of the tests, error: %v", err)
+			return nil, err
+		}
+		defer guard.Close()
+		envVars = append(envVars, guard.Env()...)
+	}
 	var cmd *exec.Cmd
 	if tasConfig.Framework == "jasmine" || tasConfig.Framework == "mocha" {
 		if collectCoverage {
-			cmd = exec.CommandContext(ctx, "nyc", commandArgs...)
+			cmd = exec.Command("nyc", commandArgs...)
 		} else {
-			cmd = exec.CommandContext(ctx, commandArgs[0], commandArgs[1:]...)
+			cmd = exec.Command(commandArgs[0], commandArgs[1:]...)
 		}
 	} else {
-		cmd = exec.CommandContext(ctx, commandArgs[0], commandArgs[1:]...)
+		cmd = exec.Command(commandArgs[0], commandArgs[1:]...)
 		if collectCoverage {
 			envVars = append(envVars, "TAS_COLLECT_COVERAGE=true")
 		}
@@ -117,8 +194,14 @@ func (tes *testExecutionService) Run(ctx context.Context,
 	cmd.Stdout = maskWriter
 	cmd.Stderr = maskWriter
 
+	if err := tes.execManager.Deprioritize(cmd); err != nil {
+		tes.logger.Warnf("failed to set the nice level of the execution command, error: %v", err)
+	}
+
 	tes.logger.Debugf("Executing test execution command: %s", cmd.String())
-	if err := cmd.Start(); err != nil {
+	// the runner is started in its own process group, so that its workers are killed along with it
+	wait, err := tes.execManager.StartProcessGroup(ctx, cmd)
+	if err != nil {
 		tes.logger.Errorf("failed to execute test %s %v", cmd.String(), err)
 		return nil, err
 	}
@@ -129,13 +212,38 @@ func (tes *testExecutionService) Run(ctx context.Context,
 		tes.logger.Errorf("failed to find process for command %s with pid %d %v", cmd.String(), pid, err)
 		return nil, err
 	}
-	if err := cmd.Wait(); err != nil {
+	if err := wait(); err != nil {
 		tes.logger.Errorf("Error in executing []: %+v\n", err)
-		return nil, err
+		return nil, &errs.CommandError{Err: err, Output: outputTail.String()}
 	}
 	execResultsWithStats := <-tes.ts.ExecutionResultOutputChannel
 	testResults = append(testResults, execResultsWithStats.TestPayload...)
 	testSuiteResults = append(testSuiteResults, execResultsWithStats.TestSuitePayload...)
+	if len(testResults) == 0 {
+		warns.Addf("No tests were executed for the patterns %s", strings.Join(target, ", "))
+	}
+	// retried tests are reported once, so that they are counted once in the status and the reports
+	testResults, err = dedupTestResults(testResults, tes.cfg.RetryResultRule)
+	if err != nil {
+		tes.logger.Errorf("failed to deduplicate test results, error: %v", err)
+		return nil, err
+	}
+	if guard != nil {
+		for _, attempt := range flagNetworkAccess(testResults, guard.Attempts()) {
+			warns.Addf("Network access to %s at %s was refused outside of a single test", attempt.Host, attempt.Time)
+		}
+	}
+	if err := attachTestOutput(testResults, tes.cfg.CaptureTestOutput, secretData); err != nil {
+		tes.logger.Errorf("failed to attach test output, error: %v", err)
+		return nil, err
+	}
+
+	if collectCoverage && len(tasConfig.CoverageExclude) > 0 {
+		if err := coverage.WriteCoverageExclude(coverageDir, tasConfig.CoverageExclude); err != nil {
+			tes.logger.Errorf("failed to write the coverage exclusions to the manifest, error: %v", err)
+			return nil, err
+		}
+	}
 
 	// FIXME:  commenting this out as we will need to rework on coverage logic after test parallelization
 	// if collectCoverage {
@@ -144,11 +252,13 @@ func (tes *testExecutionService) Run(ctx context.Context,
 	// 		return nil, err
 	// 	}
 	// }
+	limitWriter.Close()
 	azureWriter.Close()
 	if uploadErr := <-errChan; uploadErr != nil {
 		tes.logger.Errorf("failed to upload logs for test execution, error: %v", uploadErr)
 		return nil, uploadErr
 	}
+	tes.publishReports(ctx, global.RepoDir, tasConfig, payload, testResults, warns)
 	return &core.ExecutionResult{
 		OrgID:            payload.OrgID,
 		RepoID:           payload.RepoID,
@@ -157,9 +267,84 @@ func (tes *testExecutionService) Run(ctx context.Context,
 		CommitID:         payload.TargetCommit,
 		TestPayload:      testResults,
 		TestSuitePayload: testSuiteResults,
+		RunnerVersion:    runnerVersion,
+		ExecutionSeed:    seed,
+		Metadata:         commandMetadata(cmd, secretData),
+		Environment:      tes.environment(ctx, tasConfig.Framework, envMap, secretData),
 	}, nil
 }
 
+// publishReports writes the junit report and uploads the artifacts of the completed run in root. The tests
+// have completed, so the failures are logged or reported as warnings instead of discarding the test results.
+func (tes *testExecutionService) publishReports(ctx context.Context,
+	root string,
+	tasConfig *core.TASConfig,
+	payload *core.Payload,
+	testResults []core.TestPayload,
+	warns *core.Warnings) {
+	if tes.cfg.JUnitReport != "" {
+		if err := writeJUnitReport(tes.cfg.JUnitReport, testResults); err != nil {
+			tes.logger.Errorf("failed to write junit report at path %s, error: %v", tes.cfg.JUnitReport, err)
+		} else {
+			tes.logger.Debugf("junit report written at path %s", tes.cfg.JUnitReport)
+		}
+	}
+	if len(tasConfig.ArtifactPaths) > 0 {
+		artifactPath := fmt.Sprintf("%s/%s/%s/artifacts", payload.OrgID, payload.BuildID, payload.TaskID)
+		if err := tes.uploadArtifacts(ctx, root, tasConfig.ArtifactPaths, artifactPath, warns); err != nil {
+			tes.logger.Errorf("failed to upload artifacts, error: %v", err)
+			if errors.Is(err, errs.ErrUnsafeArtifactPath) {
+				warns.Addf("Unable to upload artifacts: %v", err)
+			} else {
+				warns.Addf("Unable to upload artifacts")
+			}
+		}
+	}
+}
+
+// execConcurrencyEnv returns the runner env limiting the number of concurrent test workers, which
+// the runner passes to jest as --maxWorkers and to mocha as --parallel --jobs. Frameworks without
+// such an option get no env.
+func execConcurrencyEnv(framework string, concurrency int) []string {
+	switch framework {
+	case "jest", "mocha":
+		return []string{fmt.Sprintf("%s=%d", global.ExecConcurrencyEnv, concurrency)}
+	default:
+		return nil
+	}
+}
+
+// executionOrderEnv returns the runner env executing the tests in the given order along with the seed
+// of a random order, which is generated unless configured. ok is false if the framework does not support
+// the order, the tests then run in the declared order. The runner maps the order to the framework:
+//   - jest shuffles with --randomize --seed and has no alphabetical order.
+//   - mocha sorts the test files with --sort and has no random order.
+//   - jasmine shuffles by default, so the declared order is requested with --random=false,
+//     it has no alphabetical order.
+func executionOrderEnv(framework string, order *core.ExecutionOrder) (env []string, seed int64, ok bool) {
+	switch order.Strategy {
+	case global.ExecutionOrderDeclared:
+		ok = true
+	case global.ExecutionOrderAlphabetical:
+		ok = framework == "mocha"
+	case global.ExecutionOrderRandom:
+		if framework != "jest" && framework != "jasmine" {
+			return nil, 0, false
+		}
+		seed = order.Seed
+		if seed == 0 {
+			// jest only accepts 32 bit seeds
+			seed = rand.New(rand.NewSource(time.Now().UnixNano())).Int63n(math.MaxInt32) + 1
+		}
+		return []string{global.ExecutionOrderEnv + "=" + order.Strategy,
+			global.ExecutionSeedEnv + "=" + strconv.FormatInt(seed, 10)}, seed, true
+	}
+	if !ok {
+		return nil, 0, false
+	}
+	return []string{global.ExecutionOrderEnv + "=" + order.Strategy}, 0, true
+}
+
 // func (tes *testExecutionService) createCoverageManifest(tasConfig *core.TASConfig, coverageDirectory string, removedFiles []string, executeAll bool) error {
 // 	manifestFile := core.CoverageMainfest{
 // 		Removedfiles:     removedFiles,
@@ -206,7 +391,7 @@ func (tes *testExecutionService) GetLocatorsFile(ctx context.Context, locatorAdd
 	}
 	defer resp.Close()
 
-	locatorFilePath := filepath.Join(os.TempDir(), locatorFile)
+	locatorFilePath := filepath.Join(tes.cfg.TempDir, locatorFile)
 	out, err := os.Create(locatorFilePath)
 	if err != nil {
 		return "", err
@@ -218,3 +403,27 @@ func (tes *testExecutionService) GetLocatorsFile(ctx context.Context, locatorAdd
 	}
 	return locatorFilePath, err
 }
+
+// commandMetadata returns the report metadata recording the masked command line of the runner,
+// to reproduce the run locally
+func commandMetadata(cmd *exec.Cmd, secretData map[string]string) map[string]string {
+	return map[string]string{global.CommandMetadataKey: logstream.MaskString(cmd.String(), secretData)}
+}
+
+// assignment is the record of the tests assigned to the task
+type assignment struct {
+	Locators    []string `json:"locators,omitempty"`
+	LocatorFile string   `json:"locatorFile,omitempty"`
+	Args        []string `json:"args"`
+}
+
+// writeWorkFile records v in the work directory, if one is configured. The record only serves
+// inspection, a failure is logged.
+func (tes *testExecutionService) writeWorkFile(name string, v interface{}) {
+	if tes.cfg.WorkDir == "" {
+		return
+	}
+	if err := utils.WriteWorkFile(tes.cfg.WorkDir, name, v); err != nil {
+		tes.logger.Warnf("failed to write %s to the work directory, error: %v", name, err)
+	}
+}
@@ -0,0 +1,169 @@
+package testexecutionservice
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"testing"
+
+	"github.com/LambdaTest/synapse/config"
+	"github.com/LambdaTest/synapse/pkg/core"
+	"github.com/LambdaTest/synapse/pkg/global"
+	"github.com/LambdaTest/synapse/pkg/lumber"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExecConcurrencyEnv(t *testing.T) {
+	tests := []struct {
+		framework string
+		want      []string
+	}{
+		{"jest", []string{"TAS_EXEC_CONCURRENCY=4"}},
+		{"mocha", []string{"TAS_EXEC_CONCURRENCY=4"}},
+		{"jasmine", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.framework, func(t *testing.T) {
+			assert.Equal(t, tt.want, execConcurrencyEnv(tt.framework, 4))
+		})
+	}
+}
+
+func TestExecutionOrderEnv(t *testing.T) {
+	declared := []string{"TAS_EXECUTION_ORDER=declared"}
+	random := []string{"TAS_EXECUTION_ORDER=random", "TAS_EXECUTION_SEED=42"}
+	tests := []struct {
+		name      string
+		framework string
+		strategy  string
+		want      []string
+		wantSeed  int64
+		wantOk    bool
+	}{
+		{"jest declared", "jest", global.ExecutionOrderDeclared, declared, 0, true},
+		{"jest alphabetical", "jest", global.ExecutionOrderAlphabetical, nil, 0, false},
+		{"jest random", "jest", global.ExecutionOrderRandom, random, 42, true},
+		{"mocha declared", "mocha", global.ExecutionOrderDeclared, declared, 0, true},
+		{"mocha alphabetical", "mocha", global.ExecutionOrderAlphabetical, []string{"TAS_EXECUTION_ORDER=alphabetical"}, 0, true},
+		{"mocha random", "mocha", global.ExecutionOrderRandom, nil, 0, false},
+		{"jasmine declared", "jasmine", global.ExecutionOrderDeclared, declared, 0, true},
+		{"jasmine alphabetical", "jasmine", global.ExecutionOrderAlphabetical, nil, 0, false},
+		{"jasmine random", "jasmine", global.ExecutionOrderRandom, random, 42, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, seed, ok := executionOrderEnv(tt.framework, &core.ExecutionOrder{Strategy: tt.strategy, Seed: 42})
+			assert.Equal(t, tt.want, env)
+			assert.Equal(t, tt.wantSeed, seed)
+			assert.Equal(t, tt.wantOk, ok)
+		})
+	}
+}
+
+func TestExecutionOrderGeneratedSeed(t *testing.T) {
+	env, seed, ok := executionOrderEnv("jest", &core.ExecutionOrder{Strategy: global.ExecutionOrderRandom})
+	assert.True(t, ok)
+	// the generated seed is recorded and passed to the runner
+	assert.True(t, seed > 0, "seed %d is not generated", seed)
+	assert.Equal(t, []string{"TAS_EXECUTION_ORDER=random", "TAS_EXECUTION_SEED=" + strconv.FormatInt(seed, 10)}, env)
+}
+
+func TestCommandMetadata(t *testing.T) {
+	cmd := exec.Command("jest-runner", "--command", "execute", "--config", "jest.config.js", "--token", "s3cr3t-value")
+	metadata := commandMetadata(cmd, map[string]string{"API_TOKEN": "s3cr3t-value"})
+	assert.Equal(t, map[string]string{
+		global.CommandMetadataKey: "jest-runner --command execute --config jest.config.js --token ****************",
+	}, metadata)
+}
+
+func TestEnvironment(t *testing.T) {
+	logger, err := lumber.NewLogger(lumber.LoggingConfig{EnableConsole: true}, true, lumber.InstanceZapLogger)
+	if err != nil {
+		t.Fatalf("Could not instantiate logger %s", err.Error())
+	}
+	dir := t.TempDir()
+	defer func(dir string) { runnerDir = dir }(runnerDir)
+	runnerDir = dir
+	// the framework version is the one of the package installed in the repo, not of its runner
+	if err := os.MkdirAll(filepath.Join(dir, "node_modules", "jest"), 0755); err != nil {
+		t.Fatalf("failed to create the framework package: %v", err)
+	}
+	pkg := []byte(`{"name": "jest", "version": "27.4.7"}`)
+	if err := ioutil.WriteFile(filepath.Join(dir, "node_modules", "jest", "package.json"), pkg, 0644); err != nil {
+		t.Fatalf("failed to write the framework package: %v", err)
+	}
+
+	// the node version is looked up once and cached
+	tes := &testExecutionService{logger: logger, runnerVersions: map[string]string{"node": "v16.13.0"}}
+	envMap := map[string]string{
+		"NODE_ENV":  "test",
+		"API_URL":   "https://api.example.com?token=s3cr3t-value",
+		"NPM_TOKEN": "s3cr3t-value",
+	}
+	environment := tes.environment(context.TODO(), "jest", envMap, map[string]string{"NPM_TOKEN": "s3cr3t-value"})
+	assert.Equal(t, &core.Environment{
+		Env: map[string]string{
+			"NODE_ENV":  "test",
+			"API_URL":   "https://api.example.com?token=****************",
+			"NPM_TOKEN": "****************",
+		},
+		NodeVersion:      "v16.13.0",
+		Framework:        "jest",
+		FrameworkVersion: "27.4.7",
+		OS:               runtime.GOOS,
+		Arch:             runtime.GOARCH,
+	}, environment)
+	// the env map of the configuration is left unchanged
+	assert.Equal(t, "s3cr3t-value", envMap["NPM_TOKEN"])
+
+	assert.Equal(t, "", tes.frameworkVersion("mocha"))
+}
+
+type failingAzureClient struct {
+	core.AzureClient
+}
+
+func (f *failingAzureClient) Create(ctx context.Context, path string, reader io.Reader, mimeType string) (string, error) {
+	return "", errors.New("storage unavailable")
+}
+
+func TestPublishReports(t *testing.T) {
+	logger, err := lumber.NewLogger(lumber.LoggingConfig{EnableConsole: true}, true, lumber.InstanceZapLogger)
+	if err != nil {
+		log.Fatalf("Could not instantiate logger %s", err.Error())
+	}
+	root := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(root, "e2e.log"), []byte("log"), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	payload := &core.Payload{OrgID: "org", BuildID: "build", TaskID: "task"}
+
+	// the parent of the junit report is a file, so writing it fails
+	junitReport := filepath.Join(root, "e2e.log", "junit.xml")
+
+	tests := []struct {
+		name         string
+		patterns     []string
+		wantWarnings []string
+	}{
+		{"upload failure", []string{"*.log"}, []string{"Unable to upload artifacts"}},
+		{"unsafe path", []string{"../*"}, []string{"Unable to upload artifacts: artifact path escapes the repo: ../*"}},
+		{"junit failure", nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tes := &testExecutionService{cfg: &config.NucleusConfig{JUnitReport: junitReport}, logger: logger, azureClient: &failingAzureClient{}}
+			warns := core.NewWarnings(logger)
+			testResults := []core.TestPayload{{Name: "adds", Status: "passed"}}
+			tes.publishReports(context.TODO(), root, &core.TASConfig{ArtifactPaths: tt.patterns}, payload, testResults, warns)
+			assert.Equal(t, tt.wantWarnings, warns.List())
+		})
+	}
+}
@@ -23,13 +23,16 @@ func GetDownloadURL(gitprovider, repoSlug, commitID, fileName string) (string, e
 	}
 }
 
-// GetCloneURL returns repo clone url for given git provider
-func GetCloneURL(gitprovider, repoLink, repo, commitID string) (string, error) {
+// GetCloneURL returns repo clone url in the given archive format for given git provider
+func GetCloneURL(gitprovider, repoLink, repo, commitID, format string) (string, error) {
+	if format != global.ArchiveFormatZip && format != global.ArchiveFormatTarGz {
+		return "", errs.ErrUnsupportedArchiveFormat
+	}
 	switch gitprovider {
 	case core.GitHub:
-		return fmt.Sprintf("%s/archive/%s.zip", repoLink, commitID), nil
+		return fmt.Sprintf("%s/archive/%s.%s", repoLink, commitID, format), nil
 	case core.GitLab:
-		return fmt.Sprintf("%s/-/archive/%s/%s-%s.zip", repoLink, commitID, repo, commitID), nil
+		return fmt.Sprintf("%s/-/archive/%s/%s-%s.%s", repoLink, commitID, repo, commitID, format), nil
 	default:
 		return "", errs.ErrUnsupportedGitProvider
 	}
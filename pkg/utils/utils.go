@@ -2,12 +2,18 @@ package utils
 
 import (
 	"crypto/md5"
+	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"net/http"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/LambdaTest/synapse/pkg/errs"
 	"github.com/LambdaTest/synapse/pkg/global"
+	"github.com/LambdaTest/synapse/pkg/lumber"
 )
 
 // Min returns the smaller of x or y.
@@ -70,3 +76,168 @@ func WriteFileToDirectory(path string, filename string, data []byte) error {
 func GetOutboundIP() string {
 	return global.SynapseContainerURL
 }
+
+// FrameworkEnv returns envMap merged with the default env vars of the framework. The defaults have the
+// lowest precedence, they are left out when set in envMap or in the environment of nucleus.
+func FrameworkEnv(framework string, envMap map[string]string) map[string]string {
+	merged := make(map[string]string, len(envMap)+len(global.FrameworkEnvMap[framework]))
+	for k, v := range global.FrameworkEnvMap[framework] {
+		if _, ok := os.LookupEnv(k); !ok {
+			merged[k] = v
+		}
+	}
+	for k, v := range envMap {
+		merged[k] = v
+	}
+	return merged
+}
+
+// ParseHeaders parses the headers given as key=value pairs
+func ParseHeaders(pairs []string) (map[string]string, error) {
+	headers := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+			return nil, fmt.Errorf("invalid header %q, expected key=value", pair)
+		}
+		headers[strings.TrimSpace(kv[0])] = kv[1]
+	}
+	return headers, nil
+}
+
+// ParseEnvFile parses a .env style file of KEY=VALUE lines. Blank lines, comments and the
+// export prefix are ignored, values may be wrapped in single or double quotes and are taken
+// literally without variable expansion, as secrets can contain `$`.
+func ParseEnvFile(content string) (map[string]string, error) {
+	env := make(map[string]string)
+	for i, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, "export "))
+		kv := strings.SplitN(line, "=", 2)
+		key := strings.TrimSpace(kv[0])
+		if len(kv) != 2 || key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("invalid env file line %d, expected KEY=VALUE", i+1)
+		}
+		env[key] = envFileValue(strings.TrimSpace(kv[1]))
+	}
+	return env, nil
+}
+
+// envFileValue unquotes the value of an env file line, the inline comment following it is dropped
+func envFileValue(value string) string {
+	if end := closingQuote(value); end != -1 {
+		if rest := strings.TrimSpace(value[end+1:]); rest == "" || strings.HasPrefix(rest, "#") {
+			if value[0] == '\'' {
+				return value[1:end]
+			}
+			return strings.NewReplacer(`\n`, "\n", `\"`, `"`, `\\`, `\`).Replace(value[1:end])
+		}
+	}
+	// strip inline comments from unquoted values
+	if i := strings.Index(value, " #"); i != -1 {
+		value = strings.TrimSpace(value[:i])
+	}
+	return value
+}
+
+// closingQuote returns the index of the quote closing the quoted value, or -1 if the value is not quoted.
+// Double quotes can be escaped inside double quoted values.
+func closingQuote(value string) int {
+	if len(value) < 2 || (value[0] != '"' && value[0] != '\'') {
+		return -1
+	}
+	for i := 1; i < len(value); i++ {
+		switch {
+		case value[0] == '"' && value[i] == '\\':
+			i++
+		case value[i] == value[0]:
+			return i
+		}
+	}
+	return -1
+}
+
+// WriteWorkFile writes v as indented json to the file at name within workDir. The file is written to
+// a temporary file first and renamed, so that readers never see a partial file.
+func WriteWorkFile(workDir, name string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(workDir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// AddNeuronHeaders adds the configured neuron headers to the request
+func AddNeuronHeaders(req *http.Request) {
+	for k, v := range global.NeuronHeaders {
+		req.Header.Set(k, v)
+	}
+	if global.CorrelationID != "" {
+		req.Header.Set(global.CorrelationIDHeader, global.CorrelationID)
+	}
+}
+
+// correlationLogger attaches the correlation ID of the build to the log entries once it is set
+type correlationLogger struct {
+	lumber.Logger
+}
+
+// CorrelationLogger wraps the logger to attach the correlation ID of the build to every entry, including
+// the ones of the loggers derived from it before the ID is known
+func CorrelationLogger(logger lumber.Logger) lumber.Logger {
+	return &correlationLogger{Logger: logger}
+}
+
+func (l *correlationLogger) entry() lumber.Logger {
+	if global.CorrelationID == "" {
+		return l.Logger
+	}
+	return l.Logger.WithFields(lumber.Fields{"correlationID": global.CorrelationID})
+}
+
+func (l *correlationLogger) Debugf(format string, args ...interface{}) {
+	l.entry().Debugf(format, args...)
+}
+
+func (l *correlationLogger) Infof(format string, args ...interface{}) {
+	l.entry().Infof(format, args...)
+}
+
+func (l *correlationLogger) Warnf(format string, args ...interface{}) {
+	l.entry().Warnf(format, args...)
+}
+
+func (l *correlationLogger) Errorf(format string, args ...interface{}) {
+	l.entry().Errorf(format, args...)
+}
+
+func (l *correlationLogger) Fatalf(format string, args ...interface{}) {
+	l.entry().Fatalf(format, args...)
+}
+
+func (l *correlationLogger) Panicf(format string, args ...interface{}) {
+	l.entry().Panicf(format, args...)
+}
+
+func (l *correlationLogger) WithFields(fields lumber.Fields) lumber.Logger {
+	return &correlationLogger{Logger: l.Logger.WithFields(fields)}
+}
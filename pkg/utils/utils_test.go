@@ -0,0 +1,137 @@
+package utils
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/LambdaTest/synapse/pkg/global"
+	"github.com/LambdaTest/synapse/pkg/lumber"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFrameworkEnv(t *testing.T) {
+	os.Unsetenv("NODE_ENV")
+	os.Unsetenv("FORCE_COLOR")
+	os.Setenv("CI", "1")
+	defer os.Unsetenv("CI")
+
+	tests := []struct {
+		name      string
+		framework string
+		envMap    map[string]string
+		want      map[string]string
+	}{
+		{"defaults", "jest", nil, map[string]string{"NODE_ENV": "test", "FORCE_COLOR": "0"}},
+		{"user values win", "mocha", map[string]string{"NODE_ENV": "e2e", "REPONAME": "nexe"},
+			map[string]string{"NODE_ENV": "e2e", "REPONAME": "nexe"}},
+		{"unknown framework", "ava", map[string]string{"REPONAME": "nexe"}, map[string]string{"REPONAME": "nexe"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, FrameworkEnv(tt.framework, tt.envMap))
+		})
+	}
+}
+
+func TestParseHeaders(t *testing.T) {
+	tests := []struct {
+		name    string
+		pairs   []string
+		want    map[string]string
+		wantErr bool
+	}{
+		{"no headers", nil, map[string]string{}, false},
+		{"headers", []string{"X-Api-Key=a=b", " X-Tenant-Id =tenant"}, map[string]string{"X-Api-Key": "a=b", "X-Tenant-Id": "tenant"}, false},
+		{"missing value", []string{"X-Api-Key"}, nil, true},
+		{"empty key", []string{"=value"}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseHeaders(tt.pairs)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseHeaders() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseEnvFile(t *testing.T) {
+	content := `# shared env
+NODE_ENV=test
+export API_URL = "http://localhost:3000"
+
+QUOTED='a b'
+COMMENTED="a #b" # shared with the secrets
+EMPTY=
+`
+	env, err := ParseEnvFile(content)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{
+		"NODE_ENV":  "test",
+		"API_URL":   "http://localhost:3000",
+		"QUOTED":    "a b",
+		"COMMENTED": "a #b",
+		"EMPTY":     "",
+	}, env)
+
+	_, err = ParseEnvFile("NODE_ENV=test\nnot a variable\n")
+	assert.EqualError(t, err, "invalid env file line 2, expected KEY=VALUE")
+}
+
+func TestEnvFileValue(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{`secret`, "secret"},
+		{`secret # npm`, "secret"},
+		{`"a#b $c" # aws`, "a#b $c"},
+		{`'a #b' #aws`, "a #b"},
+		{`"say \"hi\"" # greeting`, `say "hi"`},
+		{`"line\nbreak"`, "line\nbreak"},
+		{`"unterminated # value`, `"unterminated`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			assert.Equal(t, tt.want, envFileValue(tt.value))
+		})
+	}
+}
+
+func TestCorrelationID(t *testing.T) {
+	defer global.SetCorrelationID(global.CorrelationID)
+	global.SetCorrelationID("")
+
+	logFile := filepath.Join(t.TempDir(), "nucleus.log")
+	base, err := lumber.NewLogger(lumber.LoggingConfig{EnableFile: true, FileJSONFormat: true, FileLocation: logFile}, true, lumber.InstanceZapLogger)
+	assert.Nil(t, err)
+	logger := CorrelationLogger(base)
+	// services derive their loggers before the payload, and so the build ID, is fetched
+	derived := logger.WithFields(lumber.Fields{"service": "discovery"})
+	logger.Infof("fetching payload")
+
+	req, err := http.NewRequest(http.MethodPost, "http://neuron/report", nil)
+	assert.Nil(t, err)
+	AddNeuronHeaders(req)
+	assert.Empty(t, req.Header.Get(global.CorrelationIDHeader))
+
+	global.SetCorrelationID("build-42")
+	AddNeuronHeaders(req)
+	assert.Equal(t, "build-42", req.Header.Get(global.CorrelationIDHeader))
+	derived.Infof("posting report")
+
+	logs, err := ioutil.ReadFile(logFile)
+	assert.Nil(t, err)
+	lines := strings.Split(strings.TrimSpace(string(logs)), "\n")
+	if assert.Len(t, lines, 2) {
+		assert.NotContains(t, lines[0], "correlationID")
+		assert.Contains(t, lines[1], `"correlationID":"build-42"`)
+		assert.Contains(t, lines[1], `"service":"discovery"`)
+	}
+}
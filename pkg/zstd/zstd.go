@@ -5,9 +5,9 @@ import (
 	"io/ioutil"
 	"os"
 	"os/exec"
-	"path/filepath"
 	"strings"
 
+	"github.com/LambdaTest/synapse/config"
 	"github.com/LambdaTest/synapse/pkg/core"
 	"github.com/LambdaTest/synapse/pkg/global"
 	"github.com/LambdaTest/synapse/pkg/lumber"
@@ -17,6 +17,7 @@ type zstdCompressor struct {
 	logger      lumber.Logger
 	execManager core.ExecutionManager
 	execPath    string
+	tempDir     string
 }
 
 const (
@@ -25,27 +26,40 @@ const (
 )
 
 //New return zStandard compression manager
-func New(execManager core.ExecutionManager, logger lumber.Logger) (core.ZstdCompressor, error) {
+func New(execManager core.ExecutionManager, cfg *config.NucleusConfig, logger lumber.Logger) (core.ZstdCompressor, error) {
 	path, err := exec.LookPath(executableName)
 	if err != nil {
 		logger.Errorf("failed to find path for tar, error:%v", err)
 		return nil, err
 	}
 
-	return &zstdCompressor{logger: logger, execManager: execManager, execPath: path}, nil
+	return &zstdCompressor{logger: logger, execManager: execManager, execPath: path, tempDir: cfg.TempDir}, nil
 }
 
-func (z *zstdCompressor) createManifestFile(workingDir string, fileNames ...string) error {
-	return ioutil.WriteFile(filepath.Join(os.TempDir(), manifestFileName), []byte(strings.Join(fileNames, "\n")), 0660)
+// createManifestFile writes the names of the files to compress to a new manifest file, so that
+// concurrent compressions do not overwrite each other's manifest. It returns the path of the file.
+func (z *zstdCompressor) createManifestFile(fileNames ...string) (string, error) {
+	f, err := ioutil.TempFile(z.tempDir, "*-"+manifestFileName)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if _, err := f.WriteString(strings.Join(fileNames, "\n")); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
 }
 
 // Compress compress the list of files
 func (z *zstdCompressor) Compress(ctx context.Context, compressedFileName string, preservePath bool, workingDirectory string, filesToCompress ...string) error {
-	if err := z.createManifestFile(workingDirectory, filesToCompress...); err != nil {
+	manifestPath, err := z.createManifestFile(filesToCompress...)
+	if err != nil {
 		z.logger.Errorf("failed to create mainfest file %v", err)
 		return err
 	}
-	args := []string{z.execPath, "--posix", "-I", "'zstd -5 -T0'", "-cf", compressedFileName, "-C", workingDirectory, "-T", filepath.Join(os.TempDir(), manifestFileName)}
+	defer os.Remove(manifestPath)
+	args := []string{z.execPath, "--posix", "-I", "'zstd -5 -T0'", "-cf", compressedFileName, "-C", workingDirectory, "-T", manifestPath}
 	if preservePath {
 		args = append(args, "-P")
 	}
@@ -56,12 +70,13 @@ func (z *zstdCompressor) Compress(ctx context.Context, compressedFileName string
 	return nil
 }
 
-//Decompress performs the decompression operation for the given file
-func (z *zstdCompressor) Decompress(ctx context.Context, filePath string, preservePath bool, workingDirectory string) error {
+//Decompress performs the decompression operation for the given file, only the given files are extracted if any
+func (z *zstdCompressor) Decompress(ctx context.Context, filePath string, preservePath bool, workingDirectory string, filesToDecompress ...string) error {
 	args := []string{z.execPath, "--posix", "-I", "'zstd -d'", "-xf", filePath, "-C", workingDirectory}
 	if preservePath {
 		args = append(args, "-P")
 	}
+	args = append(args, filesToDecompress...)
 	if err := z.execManager.ExecuteInternalCommands(ctx, core.Zstd, args, global.RepoDir, nil, nil); err != nil {
 		z.logger.Errorf("error while zstd decompression %v", err)
 		return err